@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/mods/internal/proto"
+)
+
+const (
+	defaultAgentMaxSteps = 10
+	agentContinuePrompt  = "Continue with the next step."
+)
+
+// agentInstruction returns the system message telling the model to keep
+// working until the task is done, if in agent mode.
+func agentInstruction(cfg *Config) string {
+	if !cfg.Agent {
+		return ""
+	}
+	txt := "Work on the task step by step, using the available tools as needed, until it is complete."
+	if cfg.AgentStop != "" {
+		txt += fmt.Sprintf(" Once it is complete, and only then, say so with a line matching the regular expression %q.", cfg.AgentStop)
+	}
+	return txt
+}
+
+// agentStep is what happened in a single step of the agent loop.
+type agentStep struct {
+	tools []string
+	last  string
+}
+
+// lastAgentStep returns the tools called and the last response in the
+// messages of the current step.
+func lastAgentStep(mods *Mods) agentStep {
+	var step agentStep
+	for _, msg := range mods.messages[min(len(mods.history), len(mods.messages)):] {
+		for _, call := range msg.ToolCalls {
+			step.tools = append(step.tools, call.Function.Name)
+		}
+		if msg.Role == proto.RoleAssistant {
+			step.last = msg.Content
+		}
+	}
+	return step
+}
+
+// done returns whether the agent is done: either the stop condition matched,
+// or, without one, the model did not call any tools in this step.
+func (s agentStep) done(stop *regexp.Regexp) bool {
+	if stop != nil {
+		return stop.MatchString(s.last)
+	}
+	return len(s.tools) == 0
+}
+
+// agentPrompt returns the prompt to continue the agent loop with, until it is
+// done or reaches the max-steps.
+func agentPrompt(mods *Mods) (string, bool, error) {
+	if !config.Agent {
+		return "", false, nil
+	}
+
+	var stop *regexp.Regexp
+	if config.AgentStop != "" {
+		var err error
+		if stop, err = regexp.Compile(config.AgentStop); err != nil {
+			return "", false, modsError{err, "Invalid agent stop condition."}
+		}
+	}
+
+	step := lastAgentStep(mods)
+	n := mods.step + 1
+	if config.Verbose {
+		action := "no tools called"
+		if len(step.tools) > 0 {
+			action = "called " + strings.Join(step.tools, ", ")
+		}
+		fmt.Fprintln(os.Stderr, stderrStyles().Comment.Render(fmt.Sprintf("Step %d: %s.", n, action)))
+	}
+
+	if step.done(stop) {
+		if config.Verbose {
+			fmt.Fprintln(os.Stderr, stderrStyles().Comment.Render(fmt.Sprintf("Done after %d steps.", n)))
+		}
+		return "", false, nil
+	}
+	if n >= config.AgentMaxSteps {
+		if !config.Quiet {
+			fmt.Fprintf(
+				os.Stderr,
+				"\nStopped after %d steps without completing the task, use %s to allow more.\n",
+				n,
+				stderrStyles().InlineCode.Render("--max-steps"),
+			)
+		}
+		return "", false, nil
+	}
+	return agentContinuePrompt, true, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/charmbracelet/mods/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentStep(t *testing.T) {
+	history := []proto.Message{
+		{Role: proto.RoleUser, Content: "fix the tests"},
+		{Role: proto.RoleAssistant, ToolCalls: []proto.ToolCall{{Function: proto.Function{Name: "fs_read"}}}},
+		{Role: proto.RoleTool, Content: "..."},
+		{Role: proto.RoleAssistant, Content: "I read the file."},
+	}
+	mods := &Mods{
+		history: history,
+		messages: append(history,
+			proto.Message{Role: proto.RoleUser, Content: agentContinuePrompt},
+			proto.Message{Role: proto.RoleAssistant, ToolCalls: []proto.ToolCall{{Function: proto.Function{Name: "fs_write"}}}},
+			proto.Message{Role: proto.RoleTool, Content: "ok"},
+			proto.Message{Role: proto.RoleAssistant, Content: "Fixed it.\nTASK_COMPLETE"},
+		),
+	}
+
+	step := lastAgentStep(mods)
+	require.Equal(t, []string{"fs_write"}, step.tools)
+	require.Equal(t, "Fixed it.\nTASK_COMPLETE", step.last)
+
+	t.Run("stop condition", func(t *testing.T) {
+		require.True(t, step.done(regexp.MustCompile(`(?m)^TASK_COMPLETE$`)))
+		require.False(t, step.done(regexp.MustCompile(`ALL_DONE`)))
+	})
+
+	t.Run("no stop condition", func(t *testing.T) {
+		require.False(t, step.done(nil))
+		require.True(t, agentStep{last: "nothing else to do"}.done(nil))
+	})
+
+	t.Run("first step", func(t *testing.T) {
+		step := lastAgentStep(&Mods{messages: history})
+		require.Equal(t, []string{"fs_read"}, step.tools)
+		require.Equal(t, "I read the file.", step.last)
+	})
+}
+
+func TestAgentInstruction(t *testing.T) {
+	require.Empty(t, agentInstruction(&Config{}))
+	require.NotContains(t, agentInstruction(&Config{Agent: true}), "regular expression")
+	require.Contains(t, agentInstruction(&Config{Agent: true, AgentStop: "TASK_COMPLETE"}), `"TASK_COMPLETE"`)
+}
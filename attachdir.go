@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// The reasons a file under --attach-dir is not attached.
+const (
+	attachSkipIgnored  = "ignored by .gitignore"
+	attachSkipExcluded = "matches --exclude"
+	attachSkipIncluded = "doesn't match --include"
+	attachSkipBinary   = "binary"
+	attachSkipOverCap  = "would go over max-input-chars"
+)
+
+// attachedFile is a file, or skipped directory, found under an --attach-dir.
+type attachedFile struct {
+	path    string
+	chars   int64
+	skipped string
+}
+
+// attachDirsMessage returns the contents of the files under the given
+// directories, to be sent as context, and what was done with each of them.
+//
+// The files ignored by the .gitignore files in the directories, and the .git
+// directories, are skipped, and so are the ones that would make the context
+// longer than maxChars, unless it's negative.
+func attachDirsMessage(dirs, include, exclude []string, maxChars int64) (string, []attachedFile, error) {
+	var sb strings.Builder
+	files := []attachedFile{}
+	var size int64
+	for _, dir := range dirs {
+		root := expandHome(dir)
+		ignore := gitignore{}
+		err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, name)
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+			rel = filepath.ToSlash(rel)
+			label := path.Join(filepath.ToSlash(dir), rel)
+
+			if d.IsDir() {
+				if d.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				if rel != "." {
+					if reason := attachSkipReason(ignore, rel, true, include, exclude); reason != "" {
+						files = append(files, attachedFile{path: label + "/", skipped: reason})
+						return filepath.SkipDir
+					}
+				}
+				ignore.load(root, rel)
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			if reason := attachSkipReason(ignore, rel, false, include, exclude); reason != "" {
+				files = append(files, attachedFile{path: label, skipped: reason})
+				return nil
+			}
+
+			bts, err := os.ReadFile(name)
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+			if bytes.IndexByte(bts, 0) >= 0 || !utf8.Valid(bts) {
+				files = append(files, attachedFile{path: label, skipped: attachSkipBinary})
+				return nil
+			}
+			section := fileSection(label, string(bts))
+			file := attachedFile{path: label, chars: int64(len(section))}
+			if maxChars >= 0 && size+file.chars > maxChars {
+				file.skipped = attachSkipOverCap
+				files = append(files, file)
+				return nil
+			}
+			size += file.chars
+			sb.WriteString(section)
+			files = append(files, file)
+			return nil
+		})
+		if err != nil {
+			return "", nil, fmt.Errorf("could not read %s: %w", dir, err)
+		}
+	}
+	if sb.Len() == 0 {
+		return "", files, nil
+	}
+	return attachHeader + strings.TrimSpace(sb.String()), files, nil
+}
+
+const attachHeader = "Use these files as context:\n\n"
+
+// attachSkipReason returns why the given path, relative to the attached
+// directory, should be skipped, if it should.
+func attachSkipReason(ignore gitignore, rel string, isDir bool, include, exclude []string) string {
+	if ignore.ignored(rel, isDir) {
+		return attachSkipIgnored
+	}
+	if matchesAnyPattern(exclude, rel) {
+		return attachSkipExcluded
+	}
+	// directories are walked into, even if they don't match, to find the
+	// files that do.
+	if !isDir && len(include) > 0 && !matchesAnyPattern(include, rel) {
+		return attachSkipIncluded
+	}
+	return ""
+}
+
+// matchesAnyPattern returns whether the path, or its base name, matches any
+// of the patterns.
+func matchesAnyPattern(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateAttachPatterns checks the patterns of --include and --exclude.
+func validateAttachPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return modsError{err, fmt.Sprintf("Invalid file pattern %q.", pattern)}
+		}
+	}
+	return nil
+}
+
+// attachDirs reads the files under --attach-dir, to be sent with the prompt.
+//
+// Only the files that fit in what's left of the model's max-input-chars,
+// after the given chars already in the request, are attached, unless
+// --no-limit is set.
+func (m *Mods) attachDirs(used int64, mod Model) (string, error) {
+	cfg := m.Config
+	maxChars := int64(-1)
+	if !cfg.NoLimit {
+		maxChars = max(mod.MaxChars-used-int64(len(attachHeader)), 0)
+	}
+	txt, files, err := attachDirsMessage(cfg.AttachDirs, cfg.AttachInclude, cfg.AttachExclude, maxChars)
+	if err != nil {
+		return "", modsError{err, "Could not attach the directory."}
+	}
+	m.attached = files
+	m.attachMaxChars = mod.MaxChars
+	return txt, nil
+}
+
+// printAttachReport prints which files were attached, and which were skipped
+// and why, with --verbose. It always warns if files were skipped to stay
+// under max-input-chars, or if none were attached.
+func printAttachReport(mods *Mods) {
+	cfg := mods.Config
+	if len(cfg.AttachDirs) == 0 || mods.attached == nil {
+		return
+	}
+	overCap, attached := 0, 0
+	for _, file := range mods.attached {
+		switch file.skipped {
+		case "":
+			attached++
+		case attachSkipOverCap:
+			overCap++
+		}
+		if !cfg.Verbose {
+			continue
+		}
+		msg := fmt.Sprintf("Attached %s (%d chars).", file.path, file.chars)
+		if file.skipped != "" {
+			msg = fmt.Sprintf("Skipped %s: %s.", file.path, file.skipped)
+		}
+		fmt.Fprintln(os.Stderr, stderrStyles().Comment.Render(msg))
+	}
+	if cfg.Quiet {
+		return
+	}
+	if overCap > 0 {
+		fmt.Fprintf(
+			os.Stderr,
+			"Skipped %d attached files to stay under %s of %d, use %s to see which.\n",
+			overCap,
+			stderrStyles().InlineCode.Render("max-input-chars"),
+			mods.attachMaxChars,
+			stderrStyles().InlineCode.Render("--verbose"),
+		)
+	}
+	if attached == 0 {
+		fmt.Fprintln(os.Stderr, "No files were attached from", strings.Join(cfg.AttachDirs, ", ")+".")
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachDirsMessage(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		".gitignore":          "*.log\ngen/\n",
+		".git/config":         "[core]",
+		"main.go":             "package main\n",
+		"main_test.go":        "package main // test\n",
+		"debug.log":           "boom",
+		"README.md":           "# hi",
+		"gen/api.go":          "package gen\n",
+		"internal/x/x.go":     "package x\n",
+		"internal/x/x.bin":    "\x00\x01",
+		"internal/.gitignore": "x/x.go\n",
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	skipped := func(files []attachedFile) map[string]string {
+		result := map[string]string{}
+		for _, file := range files {
+			result[file.path[len(dir)+1:]] = file.skipped
+		}
+		return result
+	}
+
+	t.Run("all", func(t *testing.T) {
+		msg, files, err := attachDirsMessage([]string{dir}, nil, nil, -1)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			".gitignore":          "",
+			"README.md":           "",
+			"debug.log":           attachSkipIgnored,
+			"gen/":                attachSkipIgnored,
+			"internal/.gitignore": "",
+			"internal/x/x.bin":    attachSkipBinary,
+			"internal/x/x.go":     attachSkipIgnored,
+			"main.go":             "",
+			"main_test.go":        "",
+		}, skipped(files))
+		require.Contains(t, msg, "`"+filepath.Join(dir, "main.go")+"`:\n\n```go\npackage main\n```")
+		require.NotContains(t, msg, "[core]")
+	})
+
+	t.Run("include and exclude", func(t *testing.T) {
+		msg, files, err := attachDirsMessage([]string{dir}, []string{"*.go"}, []string{"*_test.go", "internal"}, -1)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			".gitignore":   attachSkipIncluded,
+			"README.md":    attachSkipIncluded,
+			"debug.log":    attachSkipIgnored,
+			"gen/":         attachSkipIgnored,
+			"internal/":    attachSkipExcluded,
+			"main.go":      "",
+			"main_test.go": attachSkipExcluded,
+		}, skipped(files))
+		require.Equal(
+			t,
+			"Use these files as context:\n\n`"+filepath.Join(dir, "main.go")+"`:\n\n```go\npackage main\n```",
+			msg,
+		)
+	})
+
+	t.Run("over max chars", func(t *testing.T) {
+		section := fileSection(filepath.Join(dir, "main.go"), "package main\n")
+		msg, files, err := attachDirsMessage([]string{dir}, []string{"main*.go"}, nil, int64(len(section)))
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			".gitignore":          attachSkipIncluded,
+			"README.md":           attachSkipIncluded,
+			"debug.log":           attachSkipIgnored,
+			"gen/":                attachSkipIgnored,
+			"internal/.gitignore": attachSkipIncluded,
+			"internal/x/x.bin":    attachSkipIncluded,
+			"internal/x/x.go":     attachSkipIgnored,
+			"main.go":             "",
+			"main_test.go":        attachSkipOverCap,
+		}, skipped(files))
+		require.NotContains(t, msg, "// test")
+	})
+
+	t.Run("nothing attached", func(t *testing.T) {
+		msg, _, err := attachDirsMessage([]string{dir}, []string{"*.rs"}, nil, -1)
+		require.NoError(t, err)
+		require.Empty(t, msg)
+	})
+
+	t.Run("missing dir", func(t *testing.T) {
+		_, _, err := attachDirsMessage([]string{filepath.Join(dir, "nope")}, nil, nil, -1)
+		require.Error(t, err)
+	})
+}
+
+func TestValidateAttachPatterns(t *testing.T) {
+	require.NoError(t, validateAttachPatterns([]string{"*.go", "internal"}))
+	err := validateAttachPatterns([]string{"*.go", "[a"})
+	require.Error(t, err)
+	require.Equal(t, `Invalid file pattern "[a".`, err.(modsError).reason)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	timeago "github.com/caarlos0/timea.go"
+	"github.com/charmbracelet/mods/internal/copilot"
+	"github.com/spf13/cobra"
+)
+
+// authStatus is the cached Copilot access token, as shown by
+// `mods auth status`.
+type authStatus struct {
+	copilot.AccessToken
+	Expires string `json:"expires"`
+}
+
+func newAuthStatus(token copilot.AccessToken) authStatus {
+	token.Token = maskSecret(token.Token)
+	return authStatus{
+		AccessToken: token,
+		Expires:     time.Unix(token.ExpiresAt, 0).Format(time.RFC3339),
+	}
+}
+
+// isAuthCmd returns whether the arguments are exactly one of the auth
+// commands, so prompts starting with "auth" are still sent as prompts.
+func isAuthCmd(args []string) bool {
+	if len(args) < 3 || args[1] != "auth" {
+		return false
+	}
+	allowed := []string{"-h", "--help"}
+	switch args[2] {
+	case "-h", "--help":
+		return len(args) == 3
+	case "status":
+		allowed = append(allowed, "--json")
+	case "refresh":
+	default:
+		return false
+	}
+	for _, arg := range args[3:] {
+		if !slices.Contains(allowed, arg) {
+			return false
+		}
+	}
+	return true
+}
+
+func newAuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Inspect and refresh the cached GitHub Copilot credentials",
+		Args:  cobra.NoArgs,
+	}
+
+	var asJSON bool
+	status := &cobra.Command{
+		Use:          "status",
+		Short:        "Show the cached GitHub Copilot access token",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			token, err := copilot.New(config.CachePath, nil).Cached()
+			if err != nil {
+				return modsError{
+					err: newUserErrorf(
+						"Run %s to get a new one.",
+						stderrStyles().InlineCode.Render("mods auth refresh"),
+					),
+					reason: "No cached Copilot access token, or it expired.",
+				}
+			}
+			return printAuthStatus(newAuthStatus(token), asJSON)
+		},
+	}
+	status.Flags().BoolVar(&asJSON, "json", false, stdoutStyles().FlagDesc.Render("Print the status as JSON"))
+
+	refresh := &cobra.Command{
+		Use:          "refresh",
+		Short:        "Get a new GitHub Copilot access token, replacing the cached one",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			httpClient, err := apiHTTPClient(&config)
+			if err != nil {
+				return modsError{err, "There was an error parsing your proxy URL."}
+			}
+			token, err := copilot.New(config.CachePath, httpClient).Refresh()
+			if err != nil {
+				return modsError{err, "Could not refresh the Copilot access token."}
+			}
+			return printAuthStatus(newAuthStatus(token), false)
+		},
+	}
+
+	cmd.AddCommand(status, refresh)
+	return cmd
+}
+
+func printAuthStatus(status authStatus, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(status); err != nil {
+			return modsError{err, "Could not encode the status."}
+		}
+		return nil
+	}
+
+	expires := status.Expires + " " + stdoutStyles().Timeago.Render(timeago.Of(time.Unix(status.ExpiresAt, 0)))
+	for _, line := range [][2]string{
+		{"Token", status.Token},
+		{"Expires", expires},
+		{"API", status.Endpoints.API},
+		{"Proxy", status.Endpoints.Proxy},
+		{"Origin tracker", status.Endpoints.OriginTracker},
+		{"Telemetry", status.Endpoints.Telemetry},
+	} {
+		fmt.Printf("%s\t%s\n", stdoutStyles().Flag.Render(line[0]+":"), line[1])
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/mods/internal/copilot"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthStatus(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var token copilot.AccessToken
+	token.Token = "tid=abcdefghijklmnopqrstuvwxyz"
+	token.ExpiresAt = now.Add(-time.Minute).Unix()
+	token.Endpoints.API = "https://api.enterprise.githubcopilot.com"
+
+	status := newAuthStatus(token)
+	require.Equal(t, "tid=****wxyz", status.Token)
+
+	bts, err := json.Marshal(status)
+	require.NoError(t, err)
+	require.NotContains(t, string(bts), "abcdefghijklmnopqrstuvwxyz")
+
+	var result map[string]any
+	require.NoError(t, json.Unmarshal(bts, &result))
+	require.Equal(t, "tid=****wxyz", result["token"])
+	require.NotContains(t, result, "expired")
+	require.Equal(t, time.Unix(token.ExpiresAt, 0).Format(time.RFC3339), result["expires"])
+	require.Equal(t, "https://api.enterprise.githubcopilot.com", result["endpoints"].(map[string]any)["api"])
+}
+
+func TestIsAuthCmd(t *testing.T) {
+	for args, is := range map[string]bool{
+		"":                       false,
+		"auth":                   false,
+		"auth flow for oauth":    false,
+		"auth status of my app":  false,
+		"auth refresh the token": false,
+		"auth -h":                true,
+		"auth status":            true,
+		"auth status --json":     true,
+		"auth status -h":         true,
+		"auth refresh":           true,
+		"auth refresh --help":    true,
+		"auth refresh --json":    false,
+	} {
+		t.Run(args, func(t *testing.T) {
+			require.Equal(t, is, isAuthCmd(append([]string{"mods"}, strings.Fields(args)...)))
+		})
+	}
+}
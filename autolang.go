@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// langHints are the patterns used to guess the language of a code block,
+// in order of preference when more than one language has the same score.
+var langHints = []struct {
+	lang     string
+	patterns []*regexp.Regexp
+}{
+	{"go", []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^package \w+$`),
+		regexp.MustCompile(`(?m)^func (\(\w+ \*?\w+\) )?\w+\(`),
+		regexp.MustCompile(`\w+ := `),
+		regexp.MustCompile(`(?m)^import \($`),
+		regexp.MustCompile(`\bfmt\.\w+\(`),
+		regexp.MustCompile(`\berr != nil\b`),
+	}},
+	{"rust", []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^\s*(pub )?fn \w+\(`),
+		regexp.MustCompile(`\blet mut \w+`),
+		regexp.MustCompile(`\w+!\(`),
+		regexp.MustCompile(`(?m)^use \w+(::\w+)+;`),
+		regexp.MustCompile(`-> \w+.*\{$`),
+	}},
+	{"python", []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^\s*def \w+\(.*\):`),
+		regexp.MustCompile(`(?m)^\s*class \w+(\(.*\))?:`),
+		regexp.MustCompile(`(?m)^(from \w+(\.\w+)* )?import \w+`),
+		regexp.MustCompile(`\bprint\(`),
+		regexp.MustCompile(`\bself\.\w+`),
+		regexp.MustCompile(`(?m)^if __name__ == .__main__.:`),
+		regexp.MustCompile(`(?m)^\s*(elif|for \w+ in) .*:$`),
+	}},
+	{"javascript", []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^\s*(const|let|var) \w+ = `),
+		regexp.MustCompile(`=> \{?`),
+		regexp.MustCompile(`\bfunction \w*\(`),
+		regexp.MustCompile(`\bconsole\.log\(`),
+		regexp.MustCompile(`\brequire\(['"]`),
+		regexp.MustCompile(`(?m)^(import .* from ['"]|export (default )?)`),
+	}},
+	{"sql", []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bselect\b.+\bfrom\b`),
+		regexp.MustCompile(`(?i)\binsert into\b`),
+		regexp.MustCompile(`(?i)\bcreate (table|index)\b`),
+		regexp.MustCompile(`(?i)\bwhere\b.+=`),
+		regexp.MustCompile(`(?i)\b(update \w+ set|delete from)\b`),
+	}},
+	{"html", []*regexp.Regexp{
+		regexp.MustCompile(`(?i)<!doctype html>`),
+		regexp.MustCompile(`(?i)<(html|head|body|div|span|p|a|ul|li|script)[\s>]`),
+		regexp.MustCompile(`</\w+>`),
+	}},
+	{"bash", []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^#!/(usr/)?bin/(env )?(ba|z)?sh`),
+		regexp.MustCompile(`(?m)^\s*(sudo|apt|apt-get|brew|echo|export|cd|ls|mkdir|rm|curl|git|go|npm|pip) `),
+		regexp.MustCompile(`\$\{?\w+\}?`),
+		regexp.MustCompile(`(?m)^\s*(if \[|fi$|then$|done$)`),
+		regexp.MustCompile(` \| (grep|awk|sed|xargs|sort|head|tail) `),
+	}},
+	{"yaml", []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^[\w-]+:( .*)?$`),
+		regexp.MustCompile(`(?m)^\s+- [\w-]+`),
+		regexp.MustCompile(`(?m)^\s+[\w-]+: .+$`),
+	}},
+}
+
+// detectLang guesses the language of the given code, returning an empty
+// string if it can't tell.
+func detectLang(code string) string {
+	trimmed := strings.TrimSpace(code)
+	if trimmed == "" {
+		return ""
+	}
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+		return "json"
+	}
+
+	var lang string
+	var best int
+	for _, hint := range langHints {
+		var score int
+		for _, p := range hint.patterns {
+			if p.MatchString(code) {
+				score++
+			}
+		}
+		if score > best {
+			lang, best = hint.lang, score
+		}
+	}
+	return lang
+}
+
+var fenceReg = regexp.MustCompile("^(\\s*)(```+|~~~+)(.*)$")
+
+// autolang adds the detected language to fenced code blocks that don't have
+// one, so they can be highlighted.
+// Blocks with an explicit language are never changed.
+func autolang(content string) string {
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		open := fenceReg.FindStringSubmatch(lines[i])
+		if open == nil {
+			continue
+		}
+		fence := open[2]
+
+		// find the closing fence, or the end of the content if the block is
+		// still being streamed.
+		end := len(lines)
+		for j := i + 1; j < len(lines); j++ {
+			if closing := fenceReg.FindStringSubmatch(lines[j]); closing != nil &&
+				strings.HasPrefix(closing[2], fence) &&
+				strings.TrimSpace(closing[3]) == "" {
+				end = j
+				break
+			}
+		}
+
+		if strings.TrimSpace(open[3]) == "" {
+			if lang := detectLang(strings.Join(lines[i+1:end], "\n")); lang != "" {
+				lines[i] = open[1] + fence + lang
+			}
+		}
+		i = end
+	}
+	return strings.Join(lines, "\n")
+}
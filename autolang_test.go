@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLang(t *testing.T) {
+	for expect, code := range map[string]string{
+		"go":         "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tmsg := \"hi\"\n\tfmt.Println(msg)\n}",
+		"python":     "import os\n\ndef main():\n    print(os.getcwd())\n\nif __name__ == '__main__':\n    main()",
+		"javascript": "const sum = (a, b) => a + b;\nconsole.log(sum(1, 2));",
+		"rust":       "fn main() {\n    let mut x = 5;\n    println!(\"{}\", x);\n}",
+		"bash":       "#!/bin/bash\nset -e\necho \"$HOME\"\nls -la | grep foo ",
+		"sql":        "SELECT id, name FROM users WHERE id = 1;",
+		"json":       "{\"foo\": [1, 2, 3]}",
+		"yaml":       "name: mods\nversion: 1\nauthors:\n  - charm",
+		"html":       "<!DOCTYPE html>\n<html>\n<body><div>hi</div></body>\n</html>",
+		"":           "just some words",
+	} {
+		t.Run(expect, func(t *testing.T) {
+			require.Equal(t, expect, detectLang(code))
+		})
+	}
+}
+
+func TestAutolang(t *testing.T) {
+	t.Run("untagged", func(t *testing.T) {
+		in := "Here:\n\n```\nSELECT * FROM users;\n```\n\nDone."
+		require.Equal(t, "Here:\n\n```sql\nSELECT * FROM users;\n```\n\nDone.", autolang(in))
+	})
+
+	t.Run("explicit tag is kept", func(t *testing.T) {
+		in := "```text\nSELECT * FROM users;\n```"
+		require.Equal(t, in, autolang(in))
+	})
+
+	t.Run("unknown is kept", func(t *testing.T) {
+		in := "```\nsome words\n```"
+		require.Equal(t, in, autolang(in))
+	})
+
+	t.Run("multiple blocks", func(t *testing.T) {
+		in := "```\n{\"a\": 1}\n```\n\n```go\nx := 1\n```\n\n~~~\ndef foo():\n    print(1)\n~~~"
+		expect := "```json\n{\"a\": 1}\n```\n\n```go\nx := 1\n```\n\n~~~python\ndef foo():\n    print(1)\n~~~"
+		require.Equal(t, expect, autolang(in))
+	})
+
+	t.Run("closing fence is not an opening one", func(t *testing.T) {
+		in := "```go\nx := 1\n```\ntext\n```\nwords\n```"
+		require.Equal(t, in, autolang(in))
+	})
+
+	t.Run("still streaming", func(t *testing.T) {
+		in := "```\nfunc main() {\n\tfoo := 1"
+		require.Equal(t, "```go\nfunc main() {\n\tfoo := 1", autolang(in))
+	})
+}
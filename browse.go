@@ -0,0 +1,425 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/atotto/clipboard"
+	timeago "github.com/caarlos0/timea.go"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/mods/internal/cache"
+	"github.com/charmbracelet/mods/internal/proto"
+)
+
+type browseAction int
+
+const (
+	browseNone browseAction = iota
+	browseShow
+	browseContinue
+)
+
+// browser is the conversation browser of --browse.
+type browser struct {
+	all     []Conversation
+	matches []Conversation
+	cursor  int
+
+	search    textinput.Model
+	searching bool
+	preview   viewport.Model
+	previews  map[string]string
+	glam      *glamour.TermRenderer
+	styles    styles
+	cache     *cache.Conversations
+
+	confirmDelete bool
+	status        string
+	width, height int
+
+	action   browseAction
+	selected *Conversation
+}
+
+func newBrowser(conversations []Conversation, convos *cache.Conversations) *browser {
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "search"
+	b := &browser{
+		all:      conversations,
+		search:   search,
+		preview:  viewport.New(0, 0),
+		previews: map[string]string{},
+		styles:   stderrStyles(),
+		cache:    convos,
+	}
+	b.filter()
+	return b
+}
+
+// Init implements tea.Model.
+func (b *browser) Init() tea.Cmd { return nil }
+
+// Update implements tea.Model.
+func (b *browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.width, b.height = msg.Width, msg.Height
+		b.glam, _ = glamour.NewTermRenderer(
+			glamour.WithEnvironmentConfig(),
+			glamour.WithWordWrap(max(msg.Width-2, 20)), //nolint:mnd
+		)
+		b.previews = map[string]string{}
+		b.preview.Width = msg.Width
+		b.preview.Height = max(msg.Height-b.listHeight()-3, 1) //nolint:mnd
+		b.updatePreview()
+		return b, nil
+	case tea.KeyMsg:
+		if b.searching {
+			return b.updateSearch(msg)
+		}
+		return b.updateList(msg)
+	}
+	return b, nil
+}
+
+func (b *browser) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return b, tea.Quit
+	case "esc":
+		b.search.SetValue("")
+		fallthrough
+	case "enter":
+		b.searching = false
+		b.search.Blur()
+		b.filter()
+		return b, nil
+	case "up", "down":
+		return b.updateList(msg)
+	}
+	var cmd tea.Cmd
+	b.search, cmd = b.search.Update(msg)
+	b.filter()
+	return b, cmd
+}
+
+func (b *browser) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	confirmDelete := b.confirmDelete
+	b.confirmDelete = false
+	b.status = ""
+
+	switch msg.String() {
+	case "q", "esc", "ctrl+c":
+		return b, tea.Quit
+	case "/":
+		b.searching = true
+		return b, b.search.Focus()
+	case "up", "k":
+		b.move(-1)
+	case "down", "j":
+		b.move(1)
+	case "pgup", "ctrl+u":
+		b.preview.HalfPageUp()
+	case "pgdown", "ctrl+d":
+		b.preview.HalfPageDown()
+	case "enter", "o":
+		return b.quitWith(browseShow)
+	case "c":
+		return b.quitWith(browseContinue)
+	case "y":
+		if convo := b.current(); convo != nil {
+			_ = clipboard.WriteAll(convo.ID)
+			b.status = "Copied " + convo.ID[:sha1short]
+		}
+	case "f":
+		b.toggleFavorite()
+	case "e":
+		b.export()
+	case "d":
+		if !confirmDelete {
+			b.confirmDelete = b.current() != nil
+			return b, nil
+		}
+		b.delete()
+	}
+	return b, nil
+}
+
+func (b *browser) quitWith(action browseAction) (tea.Model, tea.Cmd) {
+	if b.selected = b.current(); b.selected != nil {
+		b.action = action
+	}
+	return b, tea.Quit
+}
+
+func (b *browser) current() *Conversation {
+	if b.cursor < 0 || b.cursor >= len(b.matches) {
+		return nil
+	}
+	return &b.matches[b.cursor]
+}
+
+func (b *browser) move(delta int) {
+	b.cursor = max(min(b.cursor+delta, len(b.matches)-1), 0)
+	b.updatePreview()
+}
+
+// filter updates the matches with the current search.
+func (b *browser) filter() {
+	query := b.search.Value()
+	type match struct {
+		convo Conversation
+		score int
+	}
+	var matches []match
+	for _, convo := range b.all {
+		if score, ok := fuzzyScore(query, convo.Title+" "+convo.ID[:sha1short]); ok {
+			matches = append(matches, match{convo, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int { return b.score - a.score })
+
+	b.matches = b.matches[:0]
+	for _, m := range matches {
+		b.matches = append(b.matches, m.convo)
+	}
+	b.cursor = max(min(b.cursor, len(b.matches)-1), 0)
+	b.updatePreview()
+}
+
+func (b *browser) updatePreview() {
+	convo := b.current()
+	if convo == nil {
+		b.preview.SetContent(b.styles.Comment.Render("No conversations found."))
+		return
+	}
+	content, ok := b.previews[convo.ID]
+	if !ok {
+		content = b.renderPreview(convo.ID)
+		b.previews[convo.ID] = content
+	}
+	b.preview.SetContent(content)
+	b.preview.GotoTop()
+}
+
+// renderPreview renders the latest turn of the given conversation.
+func (b *browser) renderPreview(id string) string {
+	var messages []proto.Message
+	if err := b.cache.Read(id, &messages); err != nil {
+		return b.styles.ErrorDetails.Render("Could not load the conversation: " + err.Error())
+	}
+	turn := latestTurn(messages)
+	md := proto.Conversation(turn).String()
+	if b.glam == nil {
+		return md
+	}
+	out, err := b.glam.Render(md)
+	if err != nil {
+		return md
+	}
+	return strings.TrimRightFunc(out, unicode.IsSpace)
+}
+
+// latestTurn returns the last prompt and everything after it.
+func latestTurn(messages []proto.Message) []proto.Message {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == proto.RoleUser {
+			return messages[i:]
+		}
+	}
+	return messages
+}
+
+func (b *browser) toggleFavorite() {
+	convo := b.current()
+	if convo == nil {
+		return
+	}
+	if err := db.SetFavorite(convo.ID, !convo.Favorite); err != nil {
+		b.status = "Couldn't update conversation: " + err.Error()
+		return
+	}
+	for i := range b.all {
+		if b.all[i].ID == convo.ID {
+			b.all[i].Favorite = !b.all[i].Favorite
+		}
+	}
+	convo.Favorite = !convo.Favorite
+}
+
+func (b *browser) delete() {
+	convo := b.current()
+	if convo == nil {
+		return
+	}
+	id := convo.ID
+	if err := db.Delete(id); err != nil {
+		b.status = "Couldn't delete conversation: " + err.Error()
+		return
+	}
+	if err := b.cache.Delete(id); err != nil {
+		b.status = "Couldn't delete conversation: " + err.Error()
+		return
+	}
+	b.all = slices.DeleteFunc(b.all, func(c Conversation) bool { return c.ID == id })
+	delete(b.previews, id)
+	b.filter()
+	b.status = "Deleted " + id[:sha1short]
+}
+
+// export writes the selected conversation as markdown to the current
+// directory.
+func (b *browser) export() {
+	convo := b.current()
+	if convo == nil {
+		return
+	}
+	var messages []proto.Message
+	if err := b.cache.Read(convo.ID, &messages); err != nil {
+		b.status = "Couldn't load conversation: " + err.Error()
+		return
+	}
+	path := fmt.Sprintf("mods-%s.md", convo.ID[:sha1short])
+	content := "# " + convo.Title + "\n\n" + proto.Conversation(messages).String()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		b.status = "Couldn't export conversation: " + err.Error()
+		return
+	}
+	b.status = "Exported to " + path
+}
+
+func (b *browser) listHeight() int {
+	return max(min(len(b.all), (b.height-3)/2), 1) //nolint:mnd
+}
+
+// View implements tea.Model.
+func (b *browser) View() string {
+	if b.width == 0 {
+		return ""
+	}
+	height := b.listHeight()
+	start := max(0, b.cursor-height+1)
+
+	var sb strings.Builder
+	if b.searching || b.search.Value() != "" {
+		sb.WriteString(b.search.View() + "\n")
+	} else {
+		sb.WriteString(b.styles.Comment.Render(fmt.Sprintf("%d conversations", len(b.all))) + "\n")
+	}
+	for i := start; i < min(start+height, len(b.matches)); i++ {
+		convo := b.matches[i]
+		cursor := "  "
+		if i == b.cursor {
+			cursor = b.styles.Flag.Render("> ")
+		}
+		star := "  "
+		if convo.Favorite {
+			star = b.styles.Favorite.Render(favoriteStar) + " "
+		}
+		line := cursor + star + b.styles.SHA1.Render(convo.ID[:sha1short]) + " " +
+			convo.Title + " " + b.styles.Timeago.Render(timeago.Of(convo.UpdatedAt))
+		sb.WriteString(lipgloss.NewStyle().MaxWidth(b.width).Render(line) + "\n")
+	}
+	for i := len(b.matches) - start; i < height; i++ {
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(b.styles.Comment.Render(strings.Repeat("─", b.width)) + "\n")
+	sb.WriteString(b.preview.View() + "\n")
+
+	switch {
+	case b.confirmDelete:
+		sb.WriteString(b.styles.ErrorHeader.Render("DELETE") + " Press d again to delete this conversation.")
+	case b.status != "":
+		sb.WriteString(b.styles.Comment.Render(b.status))
+	case b.searching:
+		sb.WriteString(b.styles.Comment.Render("enter: done • esc: clear"))
+	default:
+		sb.WriteString(b.styles.Comment.Render(
+			"enter: show • c: continue • d: delete • f: favorite • e: export • y: copy id • /: search • q: quit",
+		))
+	}
+	return sb.String()
+}
+
+// fuzzyScore returns whether all the characters of the query are in s, in
+// order, and a score that is higher for consecutive matches and matches at
+// the start of words.
+func fuzzyScore(query, s string) (int, bool) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return 0, true
+	}
+	target := []rune(strings.ToLower(s))
+	var score, prev int
+	prev = -2
+	ti := 0
+	for _, qr := range query {
+		if unicode.IsSpace(qr) {
+			continue
+		}
+		found := false
+		for ; ti < len(target); ti++ {
+			if target[ti] != qr {
+				continue
+			}
+			score++
+			if ti == prev+1 {
+				score += 2
+			}
+			if ti == 0 || !unicode.IsLetter(target[ti-1]) && !unicode.IsDigit(target[ti-1]) {
+				score += 3
+			}
+			prev = ti
+			ti++
+			found = true
+			break
+		}
+		if !found {
+			return 0, false
+		}
+	}
+	return score, true
+}
+
+// browseConversations opens the conversation browser, returning the picked
+// action and conversation, if any.
+//
+// If not in a TTY, it prints the list of conversations instead.
+func browseConversations() (browseAction, *Conversation, error) {
+	conversations, err := db.List()
+	if err != nil {
+		return browseNone, nil, modsError{err, "Couldn't list saves."}
+	}
+	if len(conversations) == 0 {
+		fmt.Fprintln(os.Stderr, "No conversations found.")
+		return browseNone, nil, nil
+	}
+	if !isInputTTY() || !isOutputTTY() {
+		printList(conversations)
+		return browseNone, nil, nil
+	}
+
+	convos, err := cache.NewConversations(config.CachePath)
+	if err != nil {
+		return browseNone, nil, modsError{err, "Couldn't open the conversations cache."}
+	}
+	m, err := tea.NewProgram(
+		newBrowser(conversations, convos),
+		tea.WithOutput(os.Stderr),
+		tea.WithAltScreen(),
+	).Run()
+	if err != nil {
+		return browseNone, nil, modsError{err, "Couldn't start Bubble Tea program."}
+	}
+	b := m.(*browser)
+	return b.action, b.selected, nil
+}
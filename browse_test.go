@@ -0,0 +1,117 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/mods/internal/cache"
+	"github.com/charmbracelet/mods/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	t.Run("empty query matches", func(t *testing.T) {
+		_, ok := fuzzyScore("  ", "anything")
+		require.True(t, ok)
+	})
+
+	t.Run("subsequence", func(t *testing.T) {
+		_, ok := fuzzyScore("nat", "list naturals")
+		require.True(t, ok)
+		_, ok = fuzzyScore("NTRL", "list naturals")
+		require.True(t, ok)
+		_, ok = fuzzyScore("lan", "naturals")
+		require.False(t, ok)
+	})
+
+	t.Run("prefers word starts and consecutive matches", func(t *testing.T) {
+		start, _ := fuzzyScore("go", "go tests")
+		middle, _ := fuzzyScore("go", "a bigot")
+		scattered, _ := fuzzyScore("go", "gizmo")
+		require.Greater(t, start, middle)
+		require.Greater(t, start, scattered)
+	})
+}
+
+func TestLatestTurn(t *testing.T) {
+	messages := []proto.Message{
+		{Role: proto.RoleSystem, Content: "system"},
+		{Role: proto.RoleUser, Content: "first"},
+		{Role: proto.RoleAssistant, Content: "first answer"},
+		{Role: proto.RoleUser, Content: "second"},
+		{Role: proto.RoleAssistant, Content: "second answer"},
+	}
+	require.Equal(t, messages[3:], latestTurn(messages))
+	require.Equal(t, messages[:1], latestTurn(messages[:1]))
+}
+
+func TestBrowser(t *testing.T) {
+	convos, err := cache.NewConversations(t.TempDir())
+	require.NoError(t, err)
+	const testid1 = "fc5012d8c67073ea0a46a3c05488a0e1d87df74b"
+	const testid2 = "6c33f71694bf41a18c844a96d1f62f153e5f6f44"
+	conversations := []Conversation{
+		{ID: testid1, Title: "list naturals", UpdatedAt: time.Now()},
+		{ID: testid2, Title: "go tests", UpdatedAt: time.Now()},
+	}
+	key := func(s string) tea.KeyMsg {
+		switch s {
+		case "enter":
+			return tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			return tea.KeyMsg{Type: tea.KeyEsc}
+		}
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+	}
+	press := func(b *browser, keys ...string) tea.Cmd {
+		var cmd tea.Cmd
+		for _, k := range keys {
+			_, cmd = b.Update(key(k))
+		}
+		return cmd
+	}
+
+	t.Run("show", func(t *testing.T) {
+		b := newBrowser(conversations, convos)
+		press(b, "j")
+		require.NotNil(t, press(b, "enter"))
+		require.Equal(t, browseShow, b.action)
+		require.Equal(t, testid2, b.selected.ID)
+	})
+
+	t.Run("search and continue", func(t *testing.T) {
+		b := newBrowser(conversations, convos)
+		press(b, "/", "g", "o", "enter")
+		require.Len(t, b.matches, 1)
+		press(b, "c")
+		require.Equal(t, browseContinue, b.action)
+		require.Equal(t, testid2, b.selected.ID)
+	})
+
+	t.Run("esc clears the search first", func(t *testing.T) {
+		b := newBrowser(conversations, convos)
+		press(b, "/", "z", "z", "z")
+		require.Empty(t, b.matches)
+		require.Nil(t, press(b, "esc"))
+		require.Len(t, b.matches, 2)
+		require.NotNil(t, press(b, "esc"))
+		require.Equal(t, browseNone, b.action)
+		require.Nil(t, b.selected)
+	})
+
+	t.Run("quit", func(t *testing.T) {
+		b := newBrowser(conversations, convos)
+		require.NotNil(t, press(b, "q"))
+		require.Equal(t, browseNone, b.action)
+	})
+
+	t.Run("delete needs confirmation", func(t *testing.T) {
+		b := newBrowser(conversations, convos)
+		press(b, "d")
+		require.True(t, b.confirmDelete)
+		press(b, "j")
+		require.False(t, b.confirmDelete)
+		require.Len(t, b.all, 2)
+	})
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/mods/internal/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// bugReport collects what's needed to file a bug about a failed run, when
+// --bug-report is set.
+//
+// Unless includePrompt is set, it never has the prompts nor the responses,
+// and secrets are always masked.
+var bugReport *bugReporter
+
+type bugReporter struct {
+	includePrompt bool
+
+	mu      sync.Mutex
+	logs    bytes.Buffer
+	request *requestShape
+}
+
+func newBugReporter(includePrompt bool) *bugReporter {
+	return &bugReporter{includePrompt: includePrompt}
+}
+
+// promptAttrs are the log attributes that may contain parts of the prompt.
+var promptAttrs = []string{"title", "input"}
+
+const redacted = "[redacted]"
+
+// handler returns a handler that logs everything to the report, besides
+// sending it to the given one.
+func (r *bugReporter) handler(next slog.Handler) slog.Handler {
+	report := slog.NewTextHandler(lockedWriter{&r.mu, &r.logs}, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if !r.includePrompt && slices.Contains(promptAttrs, a.Key) {
+				return slog.String(a.Key, redacted)
+			}
+			return redactAttr(groups, a)
+		},
+	})
+	return fanoutHandler{next, report}
+}
+
+// setRequest records the shape of the request being sent.
+func (r *bugReporter) setRequest(req proto.Request) {
+	if r == nil {
+		return
+	}
+	shape := newRequestShape(req, r.includePrompt)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.request = &shape
+}
+
+type requestShape struct {
+	API               string         `yaml:"api"`
+	Model             string         `yaml:"model"`
+	Messages          []messageShape `yaml:"messages"`
+	Tools             []string       `yaml:"tools,omitempty"`
+	Temperature       *float64       `yaml:"temperature,omitempty"`
+	TopP              *float64       `yaml:"topp,omitempty"`
+	TopK              *int64         `yaml:"topk,omitempty"`
+	Stop              []string       `yaml:"stop,omitempty"`
+	MaxTokens         *int64         `yaml:"max-tokens,omitempty"`
+	ResponseFormat    *string        `yaml:"response-format,omitempty"`
+	ParallelToolCalls *bool          `yaml:"parallel-tool-calls,omitempty"`
+}
+
+type messageShape struct {
+	Role    string `yaml:"role"`
+	Chars   int    `yaml:"chars"`
+	Tools   int    `yaml:"tool-calls,omitempty"`
+	Content string `yaml:"content,omitempty"`
+}
+
+func newRequestShape(req proto.Request, includePrompt bool) requestShape {
+	shape := requestShape{
+		API:               req.API,
+		Model:             req.Model,
+		Temperature:       req.Temperature,
+		TopP:              req.TopP,
+		TopK:              req.TopK,
+		Stop:              req.Stop,
+		MaxTokens:         req.MaxTokens,
+		ResponseFormat:    req.ResponseFormat,
+		ParallelToolCalls: req.ParallelToolCalls,
+	}
+	for _, msg := range req.Messages {
+		m := messageShape{
+			Role:  msg.Role,
+			Chars: len(msg.Content),
+			Tools: len(msg.ToolCalls),
+		}
+		if includePrompt {
+			m.Content = msg.Content
+		}
+		shape.Messages = append(shape.Messages, m)
+	}
+	for server, tools := range req.Tools {
+		for _, tool := range tools {
+			shape.Tools = append(shape.Tools, server+"_"+tool.Name)
+		}
+	}
+	slices.Sort(shape.Tools)
+	return shape
+}
+
+// write writes the report with the given config and error, returning the path
+// of the written file.
+func (r *bugReporter) write(cfg *Config, runErr error) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sb strings.Builder
+	sb.WriteString("# mods bug report\n\n")
+	fmt.Fprintf(&sb, "- Version: %s\n", Version)
+	if CommitSHA != "" {
+		fmt.Fprintf(&sb, "- Commit: %s\n", CommitSHA)
+	}
+	fmt.Fprintf(&sb, "- OS: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&sb, "- Go: %s\n", runtime.Version())
+	fmt.Fprintf(&sb, "- Date: %s\n", time.Now().UTC().Format(time.RFC3339))
+
+	sb.WriteString("\n## Error\n\n")
+	if runErr == nil {
+		sb.WriteString("None.\n")
+	} else {
+		var merr modsError
+		if errors.As(runErr, &merr) {
+			fmt.Fprintf(&sb, "%s\n\n", redactSecrets(merr.reason))
+		}
+		fmt.Fprintf(&sb, "```\n%s\n```\n", redactSecrets(runErr.Error()))
+	}
+
+	sb.WriteString("\n## Request\n\n")
+	if r.request == nil {
+		sb.WriteString("No request was sent.\n")
+	} else if err := writeYAMLBlock(&sb, r.request); err != nil {
+		return "", err
+	}
+
+	sb.WriteString("\n## Config\n\n")
+	if err := writeYAMLBlock(&sb, reportConfig(cfg, r.includePrompt)); err != nil {
+		return "", err
+	}
+
+	sb.WriteString("\n## Logs\n\n")
+	fmt.Fprintf(&sb, "```\n%s```\n", r.logs.String())
+
+	path := filepath.Join(os.TempDir(), fmt.Sprintf("mods-bug-report-%s.md", time.Now().Format("20060102-150405")))
+	if err := os.WriteFile(path, []byte(sb.String()), 0o600); err != nil {
+		return "", fmt.Errorf("could not write bug report: %w", err)
+	}
+	return path, nil
+}
+
+func writeYAMLBlock(sb *strings.Builder, v any) error {
+	bts, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("could not encode bug report: %w", err)
+	}
+	fmt.Fprintf(sb, "```yaml\n%s```\n", bts)
+	return nil
+}
+
+// reportConfig returns a copy of the given config, with the secrets masked,
+// and without the prompt unless includePrompt is set.
+//
+// The prompt is not only the prefix: the system prompt, the roles, the format
+// texts, the title, the clarify reply, the remote prompts, and the files sent
+// as context are all part of it.
+func reportConfig(cfg *Config, includePrompt bool) Config {
+	c := *cfg
+	if !includePrompt {
+		c.Prefix = ""
+		c.System = redactString(c.System)
+		c.Title = redactString(c.Title)
+		c.Continue = redactString(c.Continue)
+		c.Show = redactString(c.Show)
+		c.ClarifyReply = redactString(c.ClarifyReply)
+		c.PromptURL = redactString(c.PromptURL)
+		c.RoleURL = redactString(c.RoleURL)
+		c.ContextFiles = redactStrings(c.ContextFiles)
+		c.AttachDirs = redactStrings(c.AttachDirs)
+
+		c.Roles = make(map[string][]string, len(cfg.Roles))
+		for name, setup := range cfg.Roles {
+			c.Roles[name] = redactStrings(setup)
+		}
+		c.FormatText = make(FormatText, len(cfg.FormatText))
+		for format, text := range cfg.FormatText {
+			c.FormatText[format] = redactString(text)
+		}
+	}
+	c.HTTPProxy = redactURL(c.HTTPProxy)
+
+	c.APIs = make(APIs, 0, len(cfg.APIs))
+	for _, api := range cfg.APIs {
+		if api.APIKey != "" {
+			api.APIKey = maskSecret(api.APIKey)
+		}
+		api.APIKeyCmd = redactSecrets(api.APIKeyCmd)
+		api.BaseURL = redactURL(api.BaseURL)
+		c.APIs = append(c.APIs, api)
+	}
+
+	c.MCPServers = make(map[string]MCPServerConfig, len(cfg.MCPServers))
+	for name, server := range cfg.MCPServers {
+		env := make([]string, 0, len(server.Env))
+		for _, kv := range server.Env {
+			k, v, ok := strings.Cut(kv, "=")
+			if ok && v != "" {
+				kv = k + "=" + maskSecret(v)
+			}
+			env = append(env, kv)
+		}
+		server.Env = env
+		args := make([]string, 0, len(server.Args))
+		for _, arg := range server.Args {
+			args = append(args, redactSecrets(arg))
+		}
+		server.Args = args
+		c.MCPServers[name] = server
+	}
+	return c
+}
+
+// redactString redacts the given string, if it's not empty.
+func redactString(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// redactStrings redacts each of the given strings.
+func redactStrings(ss []string) []string {
+	if ss == nil {
+		return nil
+	}
+	result := make([]string, 0, len(ss))
+	for _, s := range ss {
+		result = append(result, redactString(s))
+	}
+	return result
+}
+
+// sensitiveHeaders are the HTTP headers that are always redacted.
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"Api-Key",
+	"X-Goog-Api-Key",
+}
+
+// redactHeaders returns the given headers as a map, with the sensitive ones
+// redacted.
+func redactHeaders(h http.Header) map[string]string {
+	result := make(map[string]string, len(h))
+	for k, vs := range h {
+		v := strings.Join(vs, ", ")
+		if slices.Contains(sensitiveHeaders, http.CanonicalHeaderKey(k)) {
+			v = redacted
+		}
+		result[k] = v
+	}
+	return result
+}
+
+type lockedWriter struct {
+	mu  *sync.Mutex
+	buf *bytes.Buffer
+}
+
+func (w lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p) //nolint:wrapcheck
+}
+
+// fanoutHandler sends the logs to all its handlers.
+type fanoutHandler []slog.Handler
+
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, r.Level) {
+			errs = append(errs, handler.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	result := make(fanoutHandler, 0, len(h))
+	for _, handler := range h {
+		result = append(result, handler.WithAttrs(attrs))
+	}
+	return result
+}
+
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	result := make(fanoutHandler, 0, len(h))
+	for _, handler := range h {
+		result = append(result, handler.WithGroup(name))
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/mods/internal/proto"
+)
+
+var choiceReg = regexp.MustCompile(`^\s*(\d+)[.)]\s+(.+)$`)
+
+// parseChoices parses the numbered options (e.g. "1. foo", "2) bar") in the
+// given content.
+//
+// If the content has more than one numbered list, the last one is used, as
+// models usually explain things first and list the options at the end.
+func parseChoices(content string) []string {
+	var result, current []string
+	for _, line := range strings.Split(content, "\n") {
+		matches := choiceReg.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		n, err := strconv.Atoi(matches[1])
+		if err != nil {
+			continue
+		}
+		choice := strings.TrimSpace(strings.ReplaceAll(matches[2], "**", ""))
+		switch n {
+		case 1:
+			if len(current) > 1 {
+				result = current
+			}
+			current = []string{choice}
+		case len(current) + 1:
+			current = append(current, choice)
+		}
+	}
+	if len(current) > 1 {
+		result = current
+	}
+	return result
+}
+
+// choosePrompt asks the user to pick one of the numbered options in the last
+// response, returning the prompt to continue the conversation with.
+//
+// It returns false if there's nothing to choose from, if the user is done
+// choosing, or if not running interactively, in which case the options were
+// already printed along with the response.
+func choosePrompt(mods *Mods) (string, bool, error) {
+	if !config.Choose || !isInputTTY() || !isOutputTTY() {
+		return "", false, nil
+	}
+
+	var last string
+	if n := len(mods.messages); n > 0 && mods.messages[n-1].Role == proto.RoleAssistant {
+		last = mods.messages[n-1].Content
+	}
+	choices := parseChoices(last)
+	if len(choices) == 0 {
+		return "", false, nil
+	}
+
+	opts := make([]huh.Option[int], 0, len(choices)+1)
+	for i, choice := range choices {
+		opts = append(opts, huh.NewOption(fmt.Sprintf("%d. %s", i+1, choice), i))
+	}
+	opts = append(opts, huh.NewOption("Done", -1))
+
+	chosen := -1
+	err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[int]().
+				Title("Continue with:").
+				Options(opts...).
+				Value(&chosen),
+		),
+	).
+		WithTheme(themeFrom(config.Theme)).
+		Run()
+	if errors.Is(err, huh.ErrUserAborted) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, modsError{err, "Prompt failed."}
+	}
+	if chosen < 0 {
+		return "", false, nil
+	}
+	return fmt.Sprintf("Let's go with option %d: %s", chosen+1, choices[chosen]), true, nil
+}
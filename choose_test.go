@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChoices(t *testing.T) {
+	for name, tc := range map[string]struct {
+		content string
+		expect  []string
+	}{
+		"no list": {
+			content: "just some text",
+		},
+		"single item": {
+			content: "1. only one",
+		},
+		"dots": {
+			content: "Here you go:\n\n1. foo\n2. bar\n3. baz\n",
+			expect:  []string{"foo", "bar", "baz"},
+		},
+		"parens and bold": {
+			content: "1) **foo**: the first\n2) **bar**: the second",
+			expect:  []string{"foo: the first", "bar: the second"},
+		},
+		"ignores out of order": {
+			content: "1. foo\n3. nope\n2. bar",
+			expect:  []string{"foo", "bar"},
+		},
+		"last list wins": {
+			content: "Steps:\n1. think\n2. write\n\nOptions:\n1. foo\n2. bar",
+			expect:  []string{"foo", "bar"},
+		},
+		"keeps previous list if last is too short": {
+			content: "1. foo\n2. bar\n\nNote:\n1. nope",
+			expect:  []string{"foo", "bar"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expect, parseChoices(tc.content))
+		})
+	}
+}
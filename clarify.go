@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/mods/internal/proto"
+)
+
+const defaultClarifyReply = "Make your best assumption and proceed."
+
+var sentenceReg = regexp.MustCompile(`[^.!?]+[.!?]*`)
+
+// isClarifyingQuestion returns whether the given response is, for the most
+// part, a question back to the user instead of an answer.
+func isClarifyingQuestion(content string) bool {
+	content = strings.TrimSpace(content)
+	if content == "" || strings.Contains(content, "```") {
+		return false
+	}
+	if !strings.HasSuffix(content, "?") {
+		return false
+	}
+
+	var total, questions int
+	for _, sentence := range sentenceReg.FindAllString(content, -1) {
+		sentence = strings.TrimSpace(sentence)
+		if sentence == "" {
+			continue
+		}
+		total++
+		if strings.HasSuffix(sentence, "?") {
+			questions++
+		}
+	}
+	return questions*2 >= total
+}
+
+// clarifyPrompt returns the prompt to continue the conversation with if the
+// last response is a clarifying question and --auto-clarify is set.
+//
+// When running interactively, the user is asked to answer it; otherwise the
+// clarify-reply is sent, but only once, so we don't loop forever on a model
+// that keeps asking.
+func clarifyPrompt(mods *Mods) (string, bool, error) {
+	if !config.AutoClarify {
+		return "", false, nil
+	}
+
+	var last string
+	if n := len(mods.messages); n > 0 && mods.messages[n-1].Role == proto.RoleAssistant {
+		last = mods.messages[n-1].Content
+	}
+	if !isClarifyingQuestion(last) {
+		return "", false, nil
+	}
+
+	if !isInputTTY() || !isOutputTTY() {
+		if mods.history != nil {
+			return "", false, nil
+		}
+		reply := config.ClarifyReply
+		if reply == "" {
+			reply = defaultClarifyReply
+		}
+		return reply, true, nil
+	}
+
+	var answer string
+	err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewText().
+				Title("Answer the question (leave empty to stop):").
+				Value(&answer),
+		),
+	).
+		WithTheme(themeFrom(config.Theme)).
+		WithKeyMap(askInfoKeyMap()).
+		Run()
+	if errors.Is(err, huh.ErrUserAborted) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, modsError{err, "Prompt failed."}
+	}
+	answer = strings.TrimSpace(answer)
+	return answer, answer != "", nil
+}
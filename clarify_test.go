@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsClarifyingQuestion(t *testing.T) {
+	for content, expect := range map[string]bool{
+		"":                                  false,
+		"The answer is 42.":                 false,
+		"Which language do you want it in?": true,
+		"Sure. Which language? Should it be async?":                              true,
+		"Here is the script:\n\n```sh\necho hi\n```\n\nDoes that work?":          false,
+		"This is long. It explains a lot. It has many sentences. Anything else?": false,
+		"Do you mean the CLI or the library? ":                                   true,
+	} {
+		t.Run(content, func(t *testing.T) {
+			require.Equal(t, expect, isClarifyingQuestion(content))
+		})
+	}
+}
@@ -29,52 +29,118 @@ const (
 )
 
 var help = map[string]string{
-	"api":               "OpenAI compatible REST API (openai, localai, anthropic, ...)",
-	"apis":              "Aliases and endpoints for OpenAI compatible REST API",
-	"http-proxy":        "HTTP proxy to use for API requests",
-	"model":             "Default model (gpt-3.5-turbo, gpt-4, ggml-gpt4all-j...)",
-	"ask-model":         "Ask which model to use via interactive prompt",
-	"max-input-chars":   "Default character limit on input to model",
-	"format":            "Ask for the response to be formatted as markdown unless otherwise set",
-	"format-text":       "Text to append when using the -f flag",
-	"role":              "System role to use",
-	"roles":             "List of predefined system messages that can be used as roles",
-	"list-roles":        "List the roles defined in your configuration file",
-	"prompt":            "Include the prompt from the arguments and stdin, truncate stdin to specified number of lines",
-	"prompt-args":       "Include the prompt from the arguments in the response",
-	"raw":               "Render output as raw text when connected to a TTY",
-	"quiet":             "Quiet mode (hide the spinner while loading and stderr messages for success)",
-	"help":              "Show help and exit",
-	"version":           "Show version and exit",
-	"max-retries":       "Maximum number of times to retry API calls",
-	"no-limit":          "Turn off the client-side limit on the size of the input into the model",
-	"word-wrap":         "Wrap formatted output at specific width (default is 80)",
-	"max-tokens":        "Maximum number of tokens in response",
-	"temp":              "Temperature (randomness) of results, from 0.0 to 2.0, -1.0 to disable",
-	"stop":              "Up to 4 sequences where the API will stop generating further tokens",
-	"topp":              "TopP, an alternative to temperature that narrows response, from 0.0 to 1.0, -1.0 to disable",
-	"topk":              "TopK, only sample from the top K options for each subsequent token, -1 to disable",
-	"fanciness":         "Your desired level of fanciness",
-	"status-text":       "Text to show while generating",
-	"settings":          "Open settings in your $EDITOR",
-	"dirs":              "Print the directories in which mods store its data",
-	"reset-settings":    "Backup your old settings file and reset everything to the defaults",
-	"continue":          "Continue from the last response or a given save title",
-	"continue-last":     "Continue from the last response",
-	"no-cache":          "Disables caching of the prompt/response",
-	"title":             "Saves the current conversation with the given title",
-	"list":              "Lists saved conversations",
-	"delete":            "Deletes one or more saved conversations with the given titles or IDs",
-	"delete-older-than": "Deletes all saved conversations older than the specified duration; valid values are " + strings.EnglishJoin(duration.ValidUnits(), true),
-	"show":              "Show a saved conversation with the given title or ID",
-	"theme":             "Theme to use in the forms; valid choices are charm, catppuccin, dracula, and base16",
-	"show-last":         "Show the last saved conversation",
-	"editor":            "Edit the prompt in your $EDITOR; only taken into account if no other args and if STDIN is a TTY",
-	"mcp-servers":       "MCP Servers configurations",
-	"mcp-disable":       "Disable specific MCP servers",
-	"mcp-list":          "List all available MCP servers",
-	"mcp-list-tools":    "List all available tools from enabled MCP servers",
-	"mcp-timeout":       "Timeout for MCP server calls, defaults to 15 seconds",
+	"api":                       "OpenAI compatible REST API (openai, localai, anthropic, ...)",
+	"apis":                      "Aliases and endpoints for OpenAI compatible REST API",
+	"http-proxy":                "HTTP proxy to use for API requests",
+	"plugins":                   "Go plugins (.so files) with interceptors for the requests to the APIs and their responses",
+	"ip-version":                "IP version to connect to the API endpoints with; valid choices are auto, 4, and 6",
+	"model":                     "Default model (gpt-3.5-turbo, gpt-4, ggml-gpt4all-j...)",
+	"ask-model":                 "Ask which model to use via interactive prompt",
+	"max-input-chars":           "Default character limit on input to model",
+	"format":                    "Ask for the response to be formatted as markdown unless otherwise set",
+	"format-text":               "Text to append when using the -f flag",
+	"role":                      "System role to use; use 'none' to not use any role",
+	"no-role":                   "Do not use any role, not even the default one",
+	"auto-clarify":              "If the response is a question, answer it, or reply with clarify-reply when not interactive, and continue",
+	"clarify-reply":             "Reply to send to clarifying questions with --auto-clarify when not interactive",
+	"prompt-url":                "Fetch the prompt from the given URL and prepend it to the prompt; requires --allow-remote",
+	"role-url":                  "Fetch the role from the given URL and use it; requires --allow-remote",
+	"allow-remote":              "Allow fetching prompts and roles over the network",
+	"output-language":           "Language to respond in, e.g. fr or pt-BR",
+	"summarize":                 "Summarize the prompt, as plain text, with a built-in role",
+	"length":                    "Length of the summary of --summarize: short, medium, or long",
+	"judge-model":               "Model used to grade outputs with judge expectations in mods eval",
+	"choose":                    "Pick one of the numbered options in the response to continue the conversation",
+	"agent":                     "Keep going, using tools, until the task is done or max-steps is reached",
+	"max-steps":                 "Maximum number of steps in --agent mode",
+	"agent-stop":                "Regular expression the response must match for --agent to stop, e.g. TASK_COMPLETE",
+	"roles":                     "List of predefined system messages that can be used as roles",
+	"list-roles":                "List the roles defined in your configuration file",
+	"prompt":                    "Include the prompt from the arguments and stdin, truncate stdin to specified number of lines",
+	"prompt-args":               "Include the prompt from the arguments in the response",
+	"print-prompt":              "Print the assembled prompt to standard out, without sending it",
+	"raw":                       "Render output as raw text when connected to a TTY",
+	"no-autolang":               "Do not guess the language of code blocks without one",
+	"input-format":              "Parse STDIN as text, json, csv, or yaml, and include it as markdown",
+	"fence":                     "Wrap STDIN in a code block; valid choices are never, auto (only if it looks like code), and always",
+	"fence-lang":                "Language of the code block STDIN is wrapped in with --fence; detected if not set",
+	"line-buffered":             "Only print complete lines of the raw output; always on when STDOUT is not a TTY",
+	"timestamp":                 "Prefix each line of the raw output with the time it was printed",
+	"timestamp-format":          "Format of the timestamps of --timestamp, as a Go time layout",
+	"quiet":                     "Quiet mode (hide the spinner while loading and stderr messages for success)",
+	"help":                      "Show help and exit",
+	"version":                   "Show version and exit",
+	"max-retries":               "Maximum number of times to retry API calls",
+	"no-limit":                  "Turn off the client-side limit on the size of the input into the model",
+	"word-wrap":                 "Wrap formatted output at specific width (default is 80)",
+	"pager":                     "Send long formatted output to your $PAGER; valid choices are auto (only if taller than the terminal), always, and never",
+	"no-pager":                  "Do not send the output to the pager",
+	"trim-output":               "Remove blank lines at the start and whitespace at the end of the response",
+	"no-trim":                   "Do not trim the response, keep it exactly as is",
+	"env-file":                  "Load environment variables, e.g. API keys, from the given file",
+	"dotenv":                    "Load environment variables, e.g. API keys, from the .env file in the current directory, if any",
+	"bug-report":                "Write an anonymized report of the run to a file, to attach to bug reports",
+	"include-prompt":            "Include the prompts and responses in the --bug-report",
+	"max-tokens":                "Maximum number of tokens in response",
+	"temp":                      "Temperature (randomness) of results, from 0.0 to 2.0, -1.0 to disable",
+	"stop":                      "Up to 4 sequences where the API will stop generating further tokens",
+	"topp":                      "TopP, an alternative to temperature that narrows response, from 0.0 to 1.0, -1.0 to disable",
+	"topk":                      "TopK, only sample from the top K options for each subsequent token, -1 to disable",
+	"preset":                    "Sampling preset to use, which sets temp, topp, topk, and the penalties together; flags still override them",
+	"presets":                   "Named sets of sampling parameters, to use with --preset",
+	"frequency-penalty":         "Penalize tokens by how often they were used so far, from -2.0 to 2.0; only for OpenAI compatible APIs and Ollama",
+	"presence-penalty":          "Penalize tokens that were already used, from -2.0 to 2.0; only for OpenAI compatible APIs and Ollama",
+	"fanciness":                 "Your desired level of fanciness",
+	"assistant-label":           "Label to show before the response: 'model', 'role', or any text",
+	"label-color":               "Color of the assistant label",
+	"status-text":               "Text to show while generating",
+	"post-process-command":      "Command to pipe the response through before rendering and saving it",
+	"post-process-timeout":      "Timeout for the post-process-command, defaults to 10 seconds",
+	"settings":                  "Open settings in your $EDITOR",
+	"dirs":                      "Print the directories in which mods store its data",
+	"reset-settings":            "Backup your old settings file and reset everything to the defaults",
+	"continue":                  "Continue from the last response or a given save title",
+	"continue-last":             "Continue from the last response",
+	"continue-file":             "Continue the conversation in the given JSON file, creating it if missing, instead of the saved conversations",
+	"no-cache":                  "Disables caching of the prompt/response",
+	"max-conversations-per-day": "Maximum number of new conversations to save per day; unlimited if 0",
+	"max-conversations-action":  "What to do when max-conversations-per-day is reached: skip saving the conversation, or error",
+	"shell-history":             "Include the last N commands from your shell history (bash, zsh, or fish) in the prompt",
+	"log-level":                 "Log level: debug, info, warn, or error; the logs only go to standard err if given as a flag",
+	"log-file":                  "File to write the logs to, instead of standard err",
+	"log-output":                "Where to write the logs to: stderr, file (the log-file), syslog, or journald",
+	"verbose":                   "Print stats about the response to standard err",
+	"max-time-per-token":        "Warn, with --verbose, if generating each token takes longer than this",
+	"stdin-timeout":             "How long to wait for data on STDIN when it is not a TTY before giving up on it (e.g. 500ms); waits forever by default",
+	"title":                     "Saves the current conversation with the given title",
+	"list":                      "Lists saved conversations",
+	"browse":                    "Browse saved conversations interactively, to show, continue, delete, favorite, or export them",
+	"delete":                    "Deletes one or more saved conversations with the given titles or IDs",
+	"delete-older-than":         "Deletes all saved conversations older than the specified duration; valid values are " + strings.EnglishJoin(duration.ValidUnits(), true),
+	"show":                      "Show a saved conversation with the given title or ID",
+	"reverify":                  "Send the user turns of a saved conversation again, and report where the answers diverge from the saved ones",
+	"retry-last":                "Run the last command that sent a prompt again, with the same arguments and STDIN",
+	"forget-last":               "Forget the last command, so --retry-last can't run it again",
+	"favorite":                  "Mark the saved conversation with the given title or ID as a favorite",
+	"unfavorite":                "Unmark the saved conversation with the given title or ID as a favorite",
+	"favorites":                 "Only list favorite conversations, used with --list",
+	"theme":                     "Theme to use in the forms; valid choices are charm, catppuccin, dracula, and base16",
+	"show-last":                 "Show the last saved conversation",
+	"editor":                    "Edit the prompt in your $EDITOR; only taken into account if no other args and if STDIN is a TTY",
+	"mcp-servers":               "MCP Servers configurations",
+	"pools":                     "Models served by several endpoints, picked by weight, failing over to each other on errors",
+	"context-files":             "Files always sent as context when starting a conversation",
+	"no-context-files":          "Do not send the context files",
+	"attach-dir":                "Send the files under a directory as context, skipping the ones ignored by its .gitignore; can be repeated",
+	"include":                   "Only attach the files matching the pattern from --attach-dir; can be repeated",
+	"exclude":                   "Do not attach the files matching the pattern from --attach-dir; can be repeated",
+	"strict-tools":              "Ask the model again if it answers with made up tool results instead of calling the tools",
+	"strict-tools-retries":      "How many times to ask again with --strict-tools",
+	"mcp-disable":               "Disable specific MCP servers",
+	"mcp-list":                  "List all available MCP servers",
+	"mcp-list-tools":            "List all available tools from enabled MCP servers",
+	"mcp-timeout":               "Timeout for MCP server calls, defaults to 15 seconds",
+	"parallel-tool-calls":       "Allow the model to call multiple tools at once; only for OpenAI compatible APIs",
 }
 
 // Model represents the LLM model used in the API call.
@@ -85,18 +151,46 @@ type Model struct {
 	Aliases        []string `yaml:"aliases"`
 	Fallback       string   `yaml:"fallback"`
 	ThinkingBudget int      `yaml:"thinking-budget,omitempty"`
+
+	ParallelToolCalls *bool `yaml:"parallel-tool-calls,omitempty"`
+
+	// FrequencyPenalty and PresencePenalty are the defaults for this model.
+	FrequencyPenalty *float64 `yaml:"frequency-penalty,omitempty"`
+	PresencePenalty  *float64 `yaml:"presence-penalty,omitempty"`
+
+	// SystemRole overrides the system-role of the API for this model.
+	SystemRole string `yaml:"system-role,omitempty"`
+
+	// StripTokens are removed from the output, e.g. the end tokens of chat
+	// templates leaked by local models.
+	StripTokens []string `yaml:"strip-tokens,omitempty"`
+
+	// pool and endpoint are where the model was picked from, if it is from a
+	// pool.
+	pool     string
+	endpoint PoolEndpoint
 }
 
 // API represents an API endpoint and its models.
 type API struct {
-	Name      string
-	APIKey    string           `yaml:"api-key"`
-	APIKeyEnv string           `yaml:"api-key-env"`
-	APIKeyCmd string           `yaml:"api-key-cmd"`
-	Version   string           `yaml:"version"` // XXX: not used anywhere
-	BaseURL   string           `yaml:"base-url"`
-	Models    map[string]Model `yaml:"models"`
-	User      string           `yaml:"user"`
+	Name       string
+	APIKey     string           `yaml:"api-key"`
+	APIKeyEnv  string           `yaml:"api-key-env"`
+	APIKeyCmd  string           `yaml:"api-key-cmd"`
+	APIKeyFile string           `yaml:"api-key-file"`
+	Version    string           `yaml:"version"` // XXX: not used anywhere
+	BaseURL    string           `yaml:"base-url"`
+	Models     map[string]Model `yaml:"models"`
+	User       string           `yaml:"user"`
+
+	// Organization and Project are sent as the OpenAI-Organization and
+	// OpenAI-Project headers, for billing attribution.
+	Organization string `yaml:"organization"`
+	Project      string `yaml:"project"`
+
+	// SystemRole is the name the system messages are sent with, system or
+	// developer, for OpenAI compatible APIs.
+	SystemRole string `yaml:"system-role"`
 }
 
 // APIs is a type alias to allow custom YAML decoding.
@@ -159,14 +253,31 @@ type Config struct {
 	IncludePrompt       int        `yaml:"include-prompt" env:"INCLUDE_PROMPT"`
 	MaxRetries          int        `yaml:"max-retries" env:"MAX_RETRIES"`
 	WordWrap            int        `yaml:"word-wrap" env:"WORD_WRAP"`
+	Pager               string     `yaml:"pager" env:"PAGER"`
 	Fanciness           uint       `yaml:"fanciness" env:"FANCINESS"`
 	StatusText          string     `yaml:"status-text" env:"STATUS_TEXT"`
 	HTTPProxy           string     `yaml:"http-proxy" env:"HTTP_PROXY"`
+	Plugins             []string   `yaml:"plugins"`
+	IPVersion           string     `yaml:"ip-version" env:"IP_VERSION"`
 	APIs                APIs       `yaml:"apis"`
 	System              string     `yaml:"system"`
 	Role                string     `yaml:"role" env:"ROLE"`
 	AskModel            bool
 	Roles               map[string][]string
+	NoRole              bool
+	Choose              bool
+	Agent               bool
+	PromptURL           string
+	RoleURL             string
+	AllowRemote         bool `yaml:"allow-remote" env:"ALLOW_REMOTE"`
+	AutoClarify         bool
+	ShellHistory        int
+	LineBuffered        bool
+	Timestamp           bool
+	InputFormat         string
+	Fence               string `yaml:"fence" env:"FENCE"`
+	FenceLang           string
+	NoAutolang          bool `yaml:"no-autolang" env:"NO_AUTOLANG"`
 	ShowHelp            bool
 	ResetSettings       bool
 	Prefix              string
@@ -176,15 +287,31 @@ type Config struct {
 	Theme               string
 	SettingsPath        string
 	ContinueLast        bool
+	ContinueFile        string
 	Continue            string
 	Title               string
 	ShowLast            bool
 	Show                string
 	List                bool
+	Browse              bool
+	Reverify            string
+	RetryLast           bool
+	ForgetLast          bool
 	ListRoles           bool
 	Delete              []string
+	Favorite            string
+	Unfavorite          string
+	Favorites           bool
 	DeleteOlderThan     time.Duration
 	User                string
+	NoPager             bool
+	NoTrim              bool
+	PrintPrompt         bool
+	BugReport           bool
+	BugReportPrompt     bool
+
+	// Pools are models served by several endpoints, by name.
+	Pools map[string][]PoolEndpoint `yaml:"pools"`
 
 	MCPServers   map[string]MCPServerConfig `yaml:"mcp-servers"`
 	MCPList      bool
@@ -192,6 +319,64 @@ type Config struct {
 	MCPDisable   []string
 	MCPTimeout   time.Duration `yaml:"mcp-timeout" env:"MCP_TIMEOUT"`
 
+	ParallelToolCalls *bool `yaml:"parallel-tool-calls" env:"PARALLEL_TOOL_CALLS"`
+
+	StdinTimeout time.Duration `yaml:"stdin-timeout" env:"STDIN_TIMEOUT"`
+
+	PostProcessCommand string        `yaml:"post-process-command" env:"POST_PROCESS_COMMAND"`
+	PostProcessTimeout time.Duration `yaml:"post-process-timeout" env:"POST_PROCESS_TIMEOUT"`
+
+	ClarifyReply string `yaml:"clarify-reply" env:"CLARIFY_REPLY"`
+
+	OutputLanguage string `yaml:"output-language" env:"OUTPUT_LANGUAGE"`
+
+	Summarize     bool
+	SummaryLength string
+
+	JudgeModel string `yaml:"judge-model" env:"JUDGE_MODEL"`
+
+	AgentMaxSteps int    `yaml:"max-steps" env:"MAX_STEPS"`
+	AgentStop     string `yaml:"agent-stop" env:"AGENT_STOP"`
+
+	TrimOutput bool `yaml:"trim-output" env:"TRIM_OUTPUT"`
+
+	TimestampFormat string `yaml:"timestamp-format" env:"TIMESTAMP_FORMAT"`
+
+	ContextFiles   []string `yaml:"context-files" env:"CONTEXT_FILES"`
+	NoContextFiles bool
+
+	AttachDirs    []string
+	AttachInclude []string
+	AttachExclude []string
+
+	StrictTools        bool `yaml:"strict-tools" env:"STRICT_TOOLS"`
+	StrictToolsRetries int  `yaml:"strict-tools-retries" env:"STRICT_TOOLS_RETRIES"`
+
+	Dotenv  bool `yaml:"dotenv"`
+	EnvFile string
+
+	AssistantLabel string `yaml:"assistant-label" env:"ASSISTANT_LABEL"`
+	LabelColor     string `yaml:"label-color" env:"LABEL_COLOR"`
+
+	Preset  string            `yaml:"preset" env:"PRESET"`
+	Presets map[string]Preset `yaml:"presets"`
+
+	FrequencyPenalty *float64 `yaml:"frequency-penalty" env:"FREQUENCY_PENALTY"`
+	PresencePenalty  *float64 `yaml:"presence-penalty" env:"PRESENCE_PENALTY"`
+
+	MaxConversationsPerDay int    `yaml:"max-conversations-per-day" env:"MAX_CONVERSATIONS_PER_DAY"`
+	MaxConversationsAction string `yaml:"max-conversations-action" env:"MAX_CONVERSATIONS_ACTION"`
+
+	LogLevel  string `yaml:"log-level" env:"LOG_LEVEL"`
+	LogFile   string `yaml:"log-file" env:"LOG_FILE"`
+	LogOutput string `yaml:"log-output" env:"LOG_OUTPUT"`
+	// logLevelSet is whether the log level was given with a flag or in the
+	// environment, rather than the settings.
+	logLevelSet bool
+
+	Verbose         bool          `yaml:"verbose" env:"VERBOSE"`
+	MaxTimePerToken time.Duration `yaml:"max-time-per-token" env:"MAX_TIME_PER_TOKEN"`
+
 	openEditor                                         bool
 	cacheReadFromID, cacheWriteToID, cacheWriteToTitle string
 }
@@ -227,6 +412,17 @@ func ensureConfig() (Config, error) {
 		return c, modsError{err, "Could not parse settings file."}
 	}
 
+	if path := envFileArg(os.Args[1:]); path != "" {
+		if err := loadDotenv(path, true); err != nil {
+			return c, modsError{err, "Could not load the env file."}
+		}
+	}
+	if c.Dotenv {
+		if err := loadDotenv(dotenvFile, false); err != nil {
+			return c, modsError{err, "Could not load the .env file."}
+		}
+	}
+
 	if err := env.ParseWithOptions(&c, env.Options{Prefix: "MODS_"}); err != nil {
 		return c, modsError{err, "Could not parse environment into settings file."}
 	}
@@ -288,6 +484,15 @@ func defaultConfig() Config {
 			"json":     defaultJSONFormatText,
 		},
 		MCPTimeout: 15 * time.Second,
+		TrimOutput: true,
+
+		TimestampFormat: time.RFC3339,
+
+		AgentMaxSteps: defaultAgentMaxSteps,
+
+		StrictToolsRetries: defaultStrictToolsRetries,
+
+		Presets: defaultPresets(),
 	}
 }
 
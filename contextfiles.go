@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// contextFilesMessage returns the contents of the given files, to be sent as
+// context with every new conversation.
+//
+// Missing files are skipped with a warning, and so are the ones that would
+// make the context longer than maxChars, if it's positive.
+func contextFilesMessage(paths []string, maxChars int64) string {
+	var sb strings.Builder
+	var size int64
+	for _, path := range paths {
+		bts, err := os.ReadFile(expandHome(path))
+		if err != nil {
+			slog.Warn("skipping context file", "path", path, "err", err)
+			continue
+		}
+		section := fileSection(path, string(bts))
+		if maxChars > 0 && size+int64(len(section)) > maxChars {
+			slog.Warn("skipping context file, as it would go over the max input chars", "path", path, "max", maxChars)
+			continue
+		}
+		size += int64(len(section))
+		sb.WriteString(section)
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	return "Use these files as context:\n\n" + strings.TrimSpace(sb.String())
+}
+
+// fileSection returns the contents of a file, fenced and labeled with its
+// path.
+func fileSection(path, content string) string {
+	lang := strings.TrimPrefix(filepath.Ext(path), ".")
+	return fmt.Sprintf("`%s`:\n\n%s\n\n", path, fenceInput(fenceAlways, lang, content))
+}
+
+// expandHome replaces a leading ~ in the path with the user's home.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
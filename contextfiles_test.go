@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextFilesMessage(t *testing.T) {
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "schema.sql")
+	standards := filepath.Join(dir, "standards.md")
+	require.NoError(t, os.WriteFile(schema, []byte("create table users (id int);\n"), 0o600))
+	require.NoError(t, os.WriteFile(standards, []byte("Use tabs."), 0o600))
+
+	t.Run("none", func(t *testing.T) {
+		require.Empty(t, contextFilesMessage(nil, 0))
+	})
+
+	t.Run("files", func(t *testing.T) {
+		require.Equal(
+			t,
+			"Use these files as context:\n\n"+
+				"`"+schema+"`:\n\n```sql\ncreate table users (id int);\n```\n\n"+
+				"`"+standards+"`:\n\n```md\nUse tabs.\n```",
+			contextFilesMessage([]string{schema, standards}, 0),
+		)
+	})
+
+	t.Run("missing files are skipped", func(t *testing.T) {
+		msg := contextFilesMessage([]string{filepath.Join(dir, "nope.txt"), standards}, 0)
+		require.Contains(t, msg, "Use tabs.")
+		require.NotContains(t, msg, "nope.txt")
+		require.Empty(t, contextFilesMessage([]string{filepath.Join(dir, "nope.txt")}, 0))
+	})
+
+	t.Run("over max chars", func(t *testing.T) {
+		maxChars := int64(len("`" + standards + "`:\n\n```md\nUse tabs.\n```\n\n"))
+		msg := contextFilesMessage([]string{schema, standards}, maxChars)
+		require.NotContains(t, msg, "create table")
+		require.Contains(t, msg, "Use tabs.")
+	})
+}
+
+func TestExpandHome(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(home, "notes.md"), expandHome("~/notes.md"))
+	require.Equal(t, home, expandHome("~"))
+	require.Equal(t, "~user/notes.md", expandHome("~user/notes.md"))
+	require.Equal(t, "notes.md", expandHome("notes.md"))
+}
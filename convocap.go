@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/mods/internal/cache"
+)
+
+const (
+	conversationsCapSkip  = "skip"
+	conversationsCapError = "error"
+)
+
+var conversationsCapActions = []string{conversationsCapSkip, conversationsCapError}
+
+// conversationsCapWarnedID is the cache entry that tells the warning about
+// max-conversations-per-day was already printed today.
+const conversationsCapWarnedID = "conversations-cap-warned"
+
+// underConversationsCap returns whether the conversation with the given ID
+// can be saved without going over max-conversations-per-day.
+//
+// Conversations that were already saved can always be updated.
+func underConversationsCap(db *convoDB, cfg *Config, id string) (bool, error) {
+	if cfg.MaxConversationsPerDay <= 0 {
+		return true, nil
+	}
+	exists, err := db.Exists(id)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return true, nil
+	}
+	count, err := db.CountCreatedToday()
+	if err != nil {
+		return false, err
+	}
+	return count < cfg.MaxConversationsPerDay, nil
+}
+
+// conversationsCapReached errors if max-conversations-action is error, and
+// otherwise warns, once a day, that the conversations won't be saved.
+func conversationsCapReached() error {
+	if config.MaxConversationsAction == conversationsCapError {
+		return modsError{
+			err: newUserErrorf(
+				"Raise %s, or set %s to %s, to keep going.",
+				stderrStyles().InlineCode.Render("max-conversations-per-day"),
+				stderrStyles().InlineCode.Render("max-conversations-action"),
+				stderrStyles().InlineCode.Render(conversationsCapSkip),
+			),
+			reason: fmt.Sprintf("Already saved %d conversations today.", config.MaxConversationsPerDay),
+		}
+	}
+	if config.Quiet || !firstTimeToday(config.CachePath, conversationsCapWarnedID) {
+		return nil
+	}
+	fmt.Fprintf(
+		os.Stderr,
+		"\nConversation was not saved because %d conversations were already saved today, see %s.\nNew conversations won't be saved until tomorrow, and this won't be printed again today.\n",
+		config.MaxConversationsPerDay,
+		stderrStyles().InlineCode.Render("max-conversations-per-day"),
+	)
+	return nil
+}
+
+// firstTimeToday returns true the first time it is called with the given ID
+// each day.
+func firstTimeToday(cachePath, id string) bool {
+	seen, err := cache.NewExpiring[string](cachePath)
+	if err != nil {
+		return true
+	}
+	if seen.Read(id, func(io.Reader) error { return nil }) == nil {
+		return false
+	}
+	y, m, d := time.Now().Date()
+	tomorrow := time.Date(y, m, d+1, 0, 0, 0, 0, time.Local)
+	_ = seen.Write(id, tomorrow.Unix(), func(io.Writer) error { return nil })
+	return true
+}
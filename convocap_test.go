@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnderConversationsCap(t *testing.T) {
+	const testid = "df31ae23ab8b75b5643c2f846c570997edc71333"
+
+	t.Run("unlimited", func(t *testing.T) {
+		db := testDB(t)
+		require.NoError(t, db.Save(newConversationID(), "message 1", "openai", "gpt-4o"))
+		ok, err := underConversationsCap(db, &Config{}, testid)
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("under", func(t *testing.T) {
+		db := testDB(t)
+		require.NoError(t, db.Save(newConversationID(), "message 1", "openai", "gpt-4o"))
+		ok, err := underConversationsCap(db, &Config{MaxConversationsPerDay: 2}, testid)
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("reached", func(t *testing.T) {
+		db := testDB(t)
+		require.NoError(t, db.Save(newConversationID(), "message 1", "openai", "gpt-4o"))
+		require.NoError(t, db.Save(newConversationID(), "message 2", "openai", "gpt-4o"))
+		ok, err := underConversationsCap(db, &Config{MaxConversationsPerDay: 2}, testid)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("existing conversation", func(t *testing.T) {
+		db := testDB(t)
+		require.NoError(t, db.Save(testid, "message 1", "openai", "gpt-4o"))
+		ok, err := underConversationsCap(db, &Config{MaxConversationsPerDay: 1}, testid)
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+}
+
+func TestFirstTimeToday(t *testing.T) {
+	dir := t.TempDir()
+	require.True(t, firstTimeToday(dir, "warned"))
+	require.False(t, firstTimeToday(dir, "warned"))
+	require.True(t, firstTimeToday(dir, "other"))
+}
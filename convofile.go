@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/charmbracelet/mods/internal/proto"
+)
+
+// fileMessage is a message, as stored in a --continue-file.
+type fileMessage struct {
+	Role      string         `json:"role"`
+	Content   string         `json:"content"`
+	ToolCalls []fileToolCall `json:"tool_calls,omitempty"`
+}
+
+type fileToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	IsError   bool   `json:"is_error,omitempty"`
+}
+
+var fileRoles = []string{proto.RoleSystem, proto.RoleUser, proto.RoleAssistant, proto.RoleTool}
+
+// readConversationFile reads the messages of the conversation in the given
+// file, returning no messages if the file does not exist yet.
+func readConversationFile(path string) ([]proto.Message, error) {
+	bts, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	var in []fileMessage
+	if err := json.Unmarshal(bts, &in); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	messages := make([]proto.Message, 0, len(in))
+	for i, msg := range in {
+		if !slices.Contains(fileRoles, msg.Role) {
+			return nil, fmt.Errorf("%s: message %d has an invalid role %q", path, i+1, msg.Role)
+		}
+		if msg.Role == proto.RoleTool && len(msg.ToolCalls) == 0 {
+			return nil, fmt.Errorf("%s: message %d is a tool result without a tool call", path, i+1)
+		}
+		m := proto.Message{Role: msg.Role, Content: msg.Content}
+		for _, call := range msg.ToolCalls {
+			if call.ID == "" {
+				return nil, fmt.Errorf("%s: message %d has a tool call without an id", path, i+1)
+			}
+			m.ToolCalls = append(m.ToolCalls, proto.ToolCall{
+				ID:      call.ID,
+				IsError: call.IsError,
+				Function: proto.Function{
+					Name:      call.Name,
+					Arguments: []byte(call.Arguments),
+				},
+			})
+		}
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
+
+// writeConversationFile writes the messages to the given file, replacing it.
+func writeConversationFile(path string, messages []proto.Message) error {
+	out := make([]fileMessage, 0, len(messages))
+	for _, msg := range messages {
+		m := fileMessage{Role: msg.Role, Content: msg.Content}
+		for _, call := range msg.ToolCalls {
+			m.ToolCalls = append(m.ToolCalls, fileToolCall{
+				ID:        call.ID,
+				Name:      call.Function.Name,
+				Arguments: string(call.Function.Arguments),
+				IsError:   call.IsError,
+			})
+		}
+		out = append(out, m)
+	}
+	bts, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode the conversation: %w", err)
+	}
+	if err := os.WriteFile(path, append(bts, '\n'), 0o600); err != nil {
+		return fmt.Errorf("could not write %s: %w", path, err)
+	}
+	return nil
+}
+
+// saveConversationFile writes the conversation to the --continue-file.
+func saveConversationFile(mods *Mods) error {
+	if err := writeConversationFile(config.ContinueFile, mods.messages); err != nil {
+		return modsError{err, "There was a problem writing the conversation file."}
+	}
+	if !config.Quiet {
+		fmt.Fprintln(
+			os.Stderr,
+			"\nConversation saved:",
+			stderrStyles().InlineCode.Render(config.ContinueFile),
+		)
+	}
+	return nil
+}
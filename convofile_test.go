@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/mods/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversationFile(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "chat.json")
+		messages := []proto.Message{
+			{Role: proto.RoleSystem, Content: "be brief"},
+			{Role: proto.RoleUser, Content: "weather in lisbon?"},
+			{Role: proto.RoleAssistant, ToolCalls: []proto.ToolCall{{
+				ID: "call_1",
+				Function: proto.Function{
+					Name:      "weather_get",
+					Arguments: []byte(`{"city":"lisbon"}`),
+				},
+			}}},
+			{Role: proto.RoleTool, Content: "21C", ToolCalls: []proto.ToolCall{{ID: "call_1"}}},
+			{Role: proto.RoleAssistant, Content: "It's warm."},
+		}
+		require.NoError(t, writeConversationFile(path, messages))
+
+		bts, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Contains(t, string(bts), `"arguments": "{\"city\":\"lisbon\"}"`)
+
+		got, err := readConversationFile(path)
+		require.NoError(t, err)
+		require.Equal(t, messages[0], got[0])
+		require.Equal(t, "weather_get", got[2].ToolCalls[0].Function.Name)
+		require.Equal(t, messages[2].ToolCalls[0].Function.Arguments, got[2].ToolCalls[0].Function.Arguments)
+		require.Equal(t, messages[3].Content, got[3].Content)
+		require.Equal(t, messages[4], got[4])
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		got, err := readConversationFile(filepath.Join(t.TempDir(), "chat.json"))
+		require.NoError(t, err)
+		require.Empty(t, got)
+	})
+
+	for name, tc := range map[string]struct {
+		content, err string
+	}{
+		"not json":          {"hi", "could not parse"},
+		"not a list":        {`{"role": "user"}`, "could not parse"},
+		"invalid role":      {`[{"role": "bot", "content": "hi"}]`, `message 1 has an invalid role "bot"`},
+		"tool without call": {`[{"role": "user"}, {"role": "tool", "content": "21C"}]`, "message 2 is a tool result without a tool call"},
+		"call without id":   {`[{"role": "assistant", "tool_calls": [{"name": "get"}]}]`, "message 1 has a tool call without an id"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "chat.json")
+			require.NoError(t, os.WriteFile(path, []byte(tc.content), 0o600))
+			_, err := readConversationFile(path)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -77,6 +78,26 @@ func openDB(ds string) (*convoDB, error) {
 			return nil, fmt.Errorf("could not migrate db: %w", err)
 		}
 	}
+	if !hasColumn(db, "favorite") {
+		if _, err := db.Exec(`
+			ALTER TABLE conversations ADD COLUMN favorite boolean NOT NULL DEFAULT false
+		`); err != nil {
+			return nil, fmt.Errorf("could not migrate db: %w", err)
+		}
+	}
+
+	if !hasColumn(db, "created_at") {
+		if _, err := db.Exec(`
+			ALTER TABLE conversations ADD COLUMN created_at datetime
+		`); err != nil {
+			return nil, fmt.Errorf("could not migrate db: %w", err)
+		}
+	}
+	if _, err := db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_conv_created_at ON conversations (created_at)
+	`); err != nil {
+		return nil, fmt.Errorf("could not migrate db: %w", err)
+	}
 
 	return &convoDB{db: db}, nil
 }
@@ -99,11 +120,13 @@ type convoDB struct {
 
 // Conversation in the database.
 type Conversation struct {
-	ID        string    `db:"id"`
-	Title     string    `db:"title"`
-	UpdatedAt time.Time `db:"updated_at"`
-	API       *string   `db:"api"`
-	Model     *string   `db:"model"`
+	ID        string     `db:"id"`
+	Title     string     `db:"title"`
+	UpdatedAt time.Time  `db:"updated_at"`
+	API       *string    `db:"api"`
+	Model     *string    `db:"model"`
+	Favorite  bool       `db:"favorite"`
+	CreatedAt *time.Time `db:"created_at"`
 }
 
 func (c *convoDB) Close() error {
@@ -111,6 +134,7 @@ func (c *convoDB) Close() error {
 }
 
 func (c *convoDB) Save(id, title, api, model string) error {
+	slog.Debug("saving conversation", "id", id, "title", title, "api", api, "model", model)
 	res, err := c.db.Exec(c.db.Rebind(`
 		UPDATE conversations
 		SET
@@ -136,9 +160,9 @@ func (c *convoDB) Save(id, title, api, model string) error {
 
 	if _, err := c.db.Exec(c.db.Rebind(`
 		INSERT INTO
-		  conversations (id, title, api, model)
+		  conversations (id, title, api, model, created_at)
 		VALUES
-		  (?, ?, ?, ?)
+		  (?, ?, ?, ?, CURRENT_TIMESTAMP)
 	`), id, title, api, model); err != nil {
 		return fmt.Errorf("Save: %w", err)
 	}
@@ -146,7 +170,23 @@ func (c *convoDB) Save(id, title, api, model string) error {
 	return nil
 }
 
+// SetFavorite marks or unmarks the given conversation as a favorite.
+func (c *convoDB) SetFavorite(id string, favorite bool) error {
+	slog.Debug("setting favorite", "id", id, "favorite", favorite)
+	if _, err := c.db.Exec(c.db.Rebind(`
+		UPDATE conversations
+		SET
+		  favorite = ?
+		WHERE
+		  id = ?
+	`), favorite, id); err != nil {
+		return fmt.Errorf("SetFavorite: %w", err)
+	}
+	return nil
+}
+
 func (c *convoDB) Delete(id string) error {
+	slog.Debug("deleting conversation", "id", id)
 	if _, err := c.db.Exec(c.db.Rebind(`
 		DELETE FROM conversations
 		WHERE
@@ -172,6 +212,39 @@ func (c *convoDB) ListOlderThan(t time.Duration) ([]Conversation, error) {
 	return convos, nil
 }
 
+// Exists returns whether the conversation with the given ID was saved.
+func (c *convoDB) Exists(id string) (bool, error) {
+	var count int
+	if err := c.db.Get(&count, c.db.Rebind(`
+		SELECT
+		  count(*)
+		FROM
+		  conversations
+		WHERE
+		  id = ?
+	`), id); err != nil {
+		return false, fmt.Errorf("Exists: %w", err)
+	}
+	return count > 0, nil
+}
+
+// CountCreatedToday returns how many conversations were created since the
+// start of the day, in local time.
+func (c *convoDB) CountCreatedToday() (int, error) {
+	var count int
+	if err := c.db.Get(&count, `
+		SELECT
+		  count(*)
+		FROM
+		  conversations
+		WHERE
+		  created_at >= datetime ('now', 'localtime', 'start of day', 'utc')
+	`); err != nil {
+		return 0, fmt.Errorf("CountCreatedToday: %w", err)
+	}
+	return count, nil
+}
+
 func (c *convoDB) FindHEAD() (*Conversation, error) {
 	var convo Conversation
 	if err := c.db.Get(&convo, `
@@ -249,6 +322,7 @@ func (c *convoDB) Completions(in string) ([]string, error) {
 }
 
 func (c *convoDB) Find(in string) (*Conversation, error) {
+	slog.Debug("finding conversation", "input", in)
 	var conversations []Conversation
 	var err error
 
@@ -270,6 +344,7 @@ func (c *convoDB) Find(in string) (*Conversation, error) {
 	return nil, fmt.Errorf("%w: %s", errNoMatches, in)
 }
 
+// List lists all conversations, favorites first.
 func (c *convoDB) List() ([]Conversation, error) {
 	var convos []Conversation
 	if err := c.db.Select(&convos, `
@@ -278,9 +353,28 @@ func (c *convoDB) List() ([]Conversation, error) {
 		FROM
 		  conversations
 		ORDER BY
+		  favorite DESC,
 		  updated_at DESC
 	`); err != nil {
 		return convos, fmt.Errorf("List: %w", err)
 	}
 	return convos, nil
 }
+
+// ListFavorites lists the conversations marked as favorites.
+func (c *convoDB) ListFavorites() ([]Conversation, error) {
+	var convos []Conversation
+	if err := c.db.Select(&convos, `
+		SELECT
+		  *
+		FROM
+		  conversations
+		WHERE
+		  favorite
+		ORDER BY
+		  updated_at DESC
+	`); err != nil {
+		return convos, fmt.Errorf("ListFavorites: %w", err)
+	}
+	return convos, nil
+}
@@ -69,6 +69,24 @@ func TestConvoDB(t *testing.T) {
 		require.Len(t, list, 1)
 	})
 
+	t.Run("count created today", func(t *testing.T) {
+		db := testDB(t)
+
+		require.NoError(t, db.Save(testid, "message 1", "openai", "gpt-4o"))
+		require.NoError(t, db.Save(testid, "message 2", "openai", "gpt-4o"))
+		require.NoError(t, db.Save(newConversationID(), "message 3", "openai", "gpt-4o"))
+		_, err := db.db.Exec(`UPDATE conversations SET created_at = NULL WHERE id = $1`, testid)
+		require.NoError(t, err)
+
+		count, err := db.CountCreatedToday()
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		exists, err := db.Exists(testid)
+		require.NoError(t, err)
+		require.True(t, exists)
+	})
+
 	t.Run("find head single", func(t *testing.T) {
 		db := testDB(t)
 
@@ -168,4 +186,36 @@ func TestConvoDB(t *testing.T) {
 			fmt.Sprintf("%s\t%s", testid1, title1),
 		}, results)
 	})
+	t.Run("favorites", func(t *testing.T) {
+		db := testDB(t)
+
+		const testid1 = "fc5012d8c67073ea0a46a3c05488a0e1d87df74b"
+		const testid2 = "6c33f71694bf41a18c844a96d1f62f153e5f6f44"
+		require.NoError(t, db.Save(testid1, "first", "openai", "gpt-4o"))
+		time.Sleep(100 * time.Millisecond)
+		require.NoError(t, db.Save(testid2, "second", "openai", "gpt-4o"))
+
+		favorites, err := db.ListFavorites()
+		require.NoError(t, err)
+		require.Empty(t, favorites)
+
+		require.NoError(t, db.SetFavorite(testid1, true))
+
+		favorites, err = db.ListFavorites()
+		require.NoError(t, err)
+		require.Len(t, favorites, 1)
+		require.Equal(t, testid1, favorites[0].ID)
+		require.True(t, favorites[0].Favorite)
+
+		list, err := db.List()
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		require.Equal(t, testid1, list[0].ID)
+		require.Equal(t, testid2, list[1].ID)
+
+		require.NoError(t, db.SetFavorite(testid1, false))
+		favorites, err = db.ListFavorites()
+		require.NoError(t, err)
+		require.Empty(t, favorites)
+	})
 }
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// dotenvFile is the file loaded from the working directory when dotenv is
+// set in the settings.
+const dotenvFile = ".env"
+
+var dotenvKeyReg = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// loadDotenv loads the given .env file into the environment, without
+// overriding variables that are already set.
+//
+// If the file doesn't exist, it errors only if required is set.
+func loadDotenv(path string, required bool) error {
+	bts, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read env file: %w", err)
+	}
+	vars, err := parseDotenv(string(bts))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, kv := range vars {
+		if _, ok := os.LookupEnv(kv[0]); ok {
+			continue
+		}
+		if err := os.Setenv(kv[0], kv[1]); err != nil {
+			return fmt.Errorf("could not set %s: %w", kv[0], err)
+		}
+	}
+	return nil
+}
+
+// parseDotenv parses the given .env content, returning the variables in the
+// order they were defined.
+//
+// It supports comments, the export prefix, unquoted values, single quoted
+// values (taken literally), and double quoted values, which may span
+// multiple lines and have escape sequences.
+func parseDotenv(content string) ([][2]string, error) {
+	var vars [][2]string
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !dotenvKeyReg.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid line", i+1)
+		}
+		value = strings.TrimSpace(value)
+		start := i
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			// read until the closing quote, which may be in the next lines.
+			raw := value[1:]
+			for closingQuote(raw) < 0 {
+				if i++; i >= len(lines) {
+					return nil, fmt.Errorf("line %d: unterminated quoted value", start+1)
+				}
+				raw += "\n" + lines[i]
+			}
+			idx := closingQuote(raw)
+			if rest := strings.TrimSpace(raw[idx+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("line %d: unexpected content after quoted value", i+1)
+			}
+			value = unescapeDotenv(raw[:idx])
+		case strings.HasPrefix(value, `'`):
+			idx := strings.IndexByte(value[1:], '\'')
+			if idx < 0 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value", i+1)
+			}
+			value = value[1 : idx+1]
+		default:
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = value[:idx]
+			}
+			value = strings.TrimSpace(value)
+		}
+		vars = append(vars, [2]string{key, value})
+	}
+	return vars, nil
+}
+
+// closingQuote returns the index of the first unescaped double quote in s.
+func closingQuote(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+var dotenvEscapes = strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+
+func unescapeDotenv(s string) string {
+	return dotenvEscapes.Replace(s)
+}
+
+// envFileArg returns the value of --env-file in the given args, if any.
+//
+// The env file is loaded before the flags are parsed, so the variables it
+// sets apply to the settings too.
+func envFileArg(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			return ""
+		}
+		if v, ok := strings.CutPrefix(arg, "--env-file="); ok {
+			return v
+		}
+		if arg == "--env-file" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
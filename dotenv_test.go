@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDotenv(t *testing.T) {
+	vars, err := parseDotenv(`
+# a comment
+OPENAI_API_KEY=sk-123
+export ANTHROPIC_API_KEY = sk-ant-456 # inline comment
+EMPTY=
+SINGLE='literal $HOME \n # not a comment'
+DOUBLE="escaped \"quotes\"\tand\nnewlines" # comment
+MULTI="first
+second"
+HASH=abc#def
+`)
+	require.NoError(t, err)
+	require.Equal(t, [][2]string{
+		{"OPENAI_API_KEY", "sk-123"},
+		{"ANTHROPIC_API_KEY", "sk-ant-456"},
+		{"EMPTY", ""},
+		{"SINGLE", `literal $HOME \n # not a comment`},
+		{"DOUBLE", "escaped \"quotes\"\tand\nnewlines"},
+		{"MULTI", "first\nsecond"},
+		{"HASH", "abc#def"},
+	}, vars)
+
+	for name, content := range map[string]string{
+		"no equals":           "FOO",
+		"invalid key":         "FOO BAR=1",
+		"unterminated double": "FOO=\"bar\nBAZ=1",
+		"unterminated single": "FOO='bar",
+		"content after quote": `FOO="bar" baz`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseDotenv(content)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoadDotenv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("MODS_TEST_NEW=new\nMODS_TEST_SET=from-file\n"), 0o600))
+	t.Setenv("MODS_TEST_SET", "already-set")
+	t.Setenv("MODS_TEST_NEW", "")
+	require.NoError(t, os.Unsetenv("MODS_TEST_NEW"))
+
+	require.NoError(t, loadDotenv(path, true))
+	require.Equal(t, "new", os.Getenv("MODS_TEST_NEW"))
+	require.Equal(t, "already-set", os.Getenv("MODS_TEST_SET"))
+
+	t.Run("missing", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), ".env")
+		require.NoError(t, loadDotenv(missing, false))
+		require.Error(t, loadDotenv(missing, true))
+	})
+}
+
+func TestEnvFileArg(t *testing.T) {
+	require.Equal(t, "a.env", envFileArg([]string{"mods", "--env-file=a.env", "hi"}))
+	require.Equal(t, "b.env", envFileArg([]string{"mods", "--env-file", "b.env", "hi"}))
+	require.Empty(t, envFileArg([]string{"mods", "hi"}))
+	require.Empty(t, envFileArg([]string{"mods", "--", "--env-file=a.env"}))
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/charmbracelet/x/exp/ordered"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultEvalParallel = 4
+
+// evalSuite is a file of eval cases, as given to `mods eval`.
+type evalSuite struct {
+	JudgeModel string     `yaml:"judge-model"`
+	Cases      []evalCase `yaml:"cases"`
+}
+
+type evalCase struct {
+	Name   string     `yaml:"name"`
+	Input  string     `yaml:"input"`
+	API    string     `yaml:"api"`
+	Model  string     `yaml:"model"`
+	Role   string     `yaml:"role"`
+	Expect evalExpect `yaml:"expect"`
+}
+
+// evalExpect is how the output of an eval case is checked; exactly one of
+// its fields must be set.
+type evalExpect struct {
+	Exact    string `yaml:"exact"`
+	Contains string `yaml:"contains"`
+	Regex    string `yaml:"regex"`
+	// Judge is the criteria the output is graded on by the judge model.
+	Judge string `yaml:"judge"`
+}
+
+type evalResult struct {
+	Name   string
+	Pass   bool
+	Reason string
+	// Details shows what was wrong with the output, either a diff or the
+	// output itself.
+	Details string
+	Err     error
+}
+
+func loadEvalSuite(path string) (evalSuite, error) {
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return evalSuite{}, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	var suite evalSuite
+	if err := yaml.Unmarshal(bts, &suite); err != nil {
+		return evalSuite{}, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	if len(suite.Cases) == 0 {
+		return evalSuite{}, fmt.Errorf("%s has no cases", path)
+	}
+	for i := range suite.Cases {
+		c := &suite.Cases[i]
+		c.Name = ordered.First(c.Name, fmt.Sprintf("case %d", i+1))
+		if strings.TrimSpace(c.Input) == "" {
+			return evalSuite{}, fmt.Errorf("%s: input is required", c.Name)
+		}
+		var matchers int
+		for _, m := range []string{c.Expect.Exact, c.Expect.Contains, c.Expect.Regex, c.Expect.Judge} {
+			if m != "" {
+				matchers++
+			}
+		}
+		if matchers != 1 {
+			return evalSuite{}, fmt.Errorf("%s: expect needs exactly one of exact, contains, regex, or judge", c.Name)
+		}
+		if c.Expect.Regex != "" {
+			if _, err := regexp.Compile(c.Expect.Regex); err != nil {
+				return evalSuite{}, fmt.Errorf("%s: invalid regex: %w", c.Name, err)
+			}
+		}
+	}
+	return suite, nil
+}
+
+// completer sends the input to the given api, model, and role, returning the
+// full response.
+type completer func(ctx context.Context, api, model, role, input string) (string, error)
+
+// runEval runs all cases of the suite, up to parallel at a time.
+func runEval(ctx context.Context, suite evalSuite, judgeModel string, parallel int, complete completer) []evalResult {
+	results := make([]evalResult, len(suite.Cases))
+	var wg errgroup.Group
+	wg.SetLimit(max(parallel, 1))
+	for i, c := range suite.Cases {
+		wg.Go(func() error {
+			results[i] = runEvalCase(ctx, c, judgeModel, complete)
+			return nil
+		})
+	}
+	_ = wg.Wait()
+	return results
+}
+
+func runEvalCase(ctx context.Context, c evalCase, judgeModel string, complete completer) evalResult {
+	result := evalResult{Name: c.Name}
+	output, err := complete(ctx, c.API, c.Model, c.Role, c.Input)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	switch expect := c.Expect; {
+	case expect.Exact != "":
+		result.Pass = strings.TrimSpace(output) == strings.TrimSpace(expect.Exact)
+		if !result.Pass {
+			result.Reason = "output does not match"
+			result.Details = udiff.Unified("expected", "output", strings.TrimSpace(expect.Exact)+"\n", strings.TrimSpace(output)+"\n")
+		}
+	case expect.Contains != "":
+		result.Pass = strings.Contains(output, expect.Contains)
+		if !result.Pass {
+			result.Reason = fmt.Sprintf("output does not contain %q", expect.Contains)
+		}
+	case expect.Regex != "":
+		result.Pass = regexp.MustCompile(expect.Regex).MatchString(output)
+		if !result.Pass {
+			result.Reason = fmt.Sprintf("output does not match %q", expect.Regex)
+		}
+	case expect.Judge != "":
+		verdict, err := complete(ctx, "", judgeModel, roleNone, judgePrompt(expect.Judge, output))
+		if err != nil {
+			result.Err = fmt.Errorf("judge failed: %w", err)
+			return result
+		}
+		result.Pass, result.Reason = parseVerdict(verdict)
+	}
+	if !result.Pass && result.Details == "" {
+		result.Details = output
+	}
+	return result
+}
+
+func judgePrompt(criteria, output string) string {
+	return "You are grading the output of a language model.\n\n" +
+		"Criteria:\n\n" + criteria + "\n\n" +
+		"Output:\n\n" + output + "\n\n" +
+		"Does the output meet the criteria? Answer with PASS or FAIL on the first line, followed by a short reason."
+}
+
+// parseVerdict parses the response of the judge model.
+func parseVerdict(verdict string) (bool, string) {
+	first, reason, _ := strings.Cut(strings.TrimSpace(verdict), "\n")
+	first = strings.ToUpper(strings.Trim(strings.TrimSpace(first), "*#:. "))
+	reason = strings.TrimSpace(reason)
+	switch {
+	case strings.HasPrefix(first, "PASS"):
+		return true, reason
+	case strings.HasPrefix(first, "FAIL"):
+		return false, ordered.First(reason, "judge said it fails")
+	default:
+		return false, "could not understand the judge: " + strings.TrimSpace(verdict)
+	}
+}
+
+// evalCompleter returns a [completer] that uses the given config, with the
+// api, model, and role of each case.
+func evalCompleter(cfg *Config) completer {
+	return func(ctx context.Context, api, model, role, input string) (string, error) {
+		c := *cfg
+		if model != "" {
+			// the model might not be in the configured api.
+			c.Model, c.API = model, api
+		} else if api != "" {
+			c.API = api
+		}
+		c.Role = ordered.First(role, c.Role)
+		c.Prefix, c.PromptURL, c.ShellHistory = "", "", 0
+		c.Show, c.ShowLast = "", false
+		c.cacheReadFromID, c.cacheWriteToID = "", ""
+		return newMods(ctx, stdoutRenderer(), &c, nil, nil).complete(input)
+	}
+}
+
+// complete sends the given content, and returns the full response, without
+// rendering anything.
+func (m *Mods) complete(content string) (string, error) {
+	var sb strings.Builder
+	msg := m.startCompletionCmd(content)()
+	for {
+		switch out := msg.(type) {
+		case completionInput:
+			// retrying
+			sb.Reset()
+			msg = m.startCompletionCmd(out.content)()
+		case completionOutput:
+			if out.stream == nil {
+				return sb.String(), nil
+			}
+			sb.WriteString(out.content)
+			msg = m.receiveCompletionStreamCmd(out)()
+		case error:
+			return "", out
+		default:
+			return "", fmt.Errorf("unexpected message: %T", out)
+		}
+	}
+}
+
+// isEvalCmd returns whether the arguments are the eval command: "eval"
+// followed by its flags and a single file that exists, so prompts starting
+// with "eval" are still sent as prompts.
+func isEvalCmd(args []string) bool {
+	if len(args) < 3 || args[1] != "eval" {
+		return false
+	}
+	if len(args) == 3 && (args[2] == "-h" || args[2] == "--help") {
+		return true
+	}
+	var files []string
+	for i := 2; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-j" || arg == "--parallel" || arg == "--judge-model":
+			i++
+		case strings.HasPrefix(arg, "-j"), strings.HasPrefix(arg, "--parallel="), strings.HasPrefix(arg, "--judge-model="):
+		case strings.HasPrefix(arg, "-"):
+			return false
+		default:
+			files = append(files, arg)
+		}
+	}
+	if len(files) != 1 {
+		return false
+	}
+	info, err := os.Stat(files[0])
+	return err == nil && info.Mode().IsRegular()
+}
+
+func newEvalCmd() *cobra.Command {
+	var parallel int
+	var judgeModel string
+	cmd := &cobra.Command{
+		Use:          "eval cases.yml",
+		Short:        "Run the cases in the given file, and check their outputs",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			suite, err := loadEvalSuite(args[0])
+			if err != nil {
+				return modsError{err, "Invalid eval file."}
+			}
+			judge := ordered.First(judgeModel, suite.JudgeModel, config.JudgeModel, config.Model)
+			results := runEval(cmd.Context(), suite, judge, parallel, evalCompleter(&config))
+			if failed := printEvalResults(results); failed > 0 {
+				return modsError{
+					err:    fmt.Errorf("%d of %d cases failed", failed, len(results)),
+					reason: "Eval failed.",
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&parallel, "parallel", "j", defaultEvalParallel, stdoutStyles().FlagDesc.Render("Number of cases to run at the same time"))
+	cmd.Flags().StringVar(&judgeModel, "judge-model", "", stdoutStyles().FlagDesc.Render(help["judge-model"]))
+	return cmd
+}
+
+// printEvalResults prints the results and a summary, returning how many cases
+// failed.
+func printEvalResults(results []evalResult) int {
+	styles := stdoutStyles()
+	var failed int
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed++
+			msg := r.Err.Error()
+			var merr modsError
+			if errors.As(r.Err, &merr) {
+				msg = merr.reason + " " + merr.err.Error()
+			}
+			fmt.Printf("%s %s: %s\n", styles.ErrorHeader.Render("ERROR"), r.Name, msg)
+		case r.Pass:
+			fmt.Printf("%s %s\n", styles.Flag.Render("PASS"), r.Name)
+		default:
+			failed++
+			fmt.Printf("%s %s: %s\n", styles.ErrorHeader.Render("FAIL"), r.Name, r.Reason)
+			if r.Details != "" {
+				fmt.Println(styles.Comment.Render(indent(r.Details)))
+			}
+		}
+	}
+	fmt.Printf("\n%d passed, %d failed\n", len(results)-failed, failed)
+	return failed
+}
+
+func indent(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i := range lines {
+		lines[i] = "    " + lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
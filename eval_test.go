@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEvalSuite(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "cases.yml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		suite, err := loadEvalSuite(write(t, `
+judge-model: judge
+cases:
+  - input: first 2 primes
+    model: gpt-4o
+    expect:
+      contains: "3"
+  - name: named
+    input: say hi
+    role: shell
+    expect:
+      regex: (?i)^hi
+`))
+		require.NoError(t, err)
+		require.Equal(t, "judge", suite.JudgeModel)
+		require.Len(t, suite.Cases, 2)
+		require.Equal(t, "case 1", suite.Cases[0].Name)
+		require.Equal(t, "gpt-4o", suite.Cases[0].Model)
+		require.Equal(t, "named", suite.Cases[1].Name)
+		require.Equal(t, "shell", suite.Cases[1].Role)
+	})
+
+	for name, tc := range map[string]struct {
+		content, err string
+	}{
+		"no cases":      {"cases: []", "has no cases"},
+		"no input":      {"cases: [{expect: {exact: a}}]", "case 1: input is required"},
+		"no matcher":    {"cases: [{input: a}]", "case 1: expect needs exactly one"},
+		"many matchers": {"cases: [{input: a, expect: {exact: a, contains: a}}]", "case 1: expect needs exactly one"},
+		"invalid regex": {"cases: [{input: a, expect: {regex: '('}}]", "case 1: invalid regex"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadEvalSuite(write(t, tc.content))
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestRunEval(t *testing.T) {
+	responses := map[string]string{
+		"exact":    "hello world",
+		"contains": "the answer is 42",
+		"regex":    "2, 3",
+		"judge":    "a haiku",
+	}
+	complete := func(_ context.Context, _, model, _, input string) (string, error) {
+		if model == "judge" {
+			if strings.Contains(input, "Output:\n\na haiku") {
+				return "PASS\nit is a haiku", nil
+			}
+			return "FAIL\nnot a haiku", nil
+		}
+		if input == "error" {
+			return "", errors.New("boom")
+		}
+		return responses[input], nil
+	}
+
+	suite := evalSuite{Cases: []evalCase{
+		{Name: "exact", Input: "exact", Expect: evalExpect{Exact: "hello world\n"}},
+		{Name: "exact fail", Input: "exact", Expect: evalExpect{Exact: "hello there"}},
+		{Name: "contains", Input: "contains", Expect: evalExpect{Contains: "42"}},
+		{Name: "contains fail", Input: "contains", Expect: evalExpect{Contains: "43"}},
+		{Name: "regex", Input: "regex", Expect: evalExpect{Regex: `^\d+, \d+$`}},
+		{Name: "judge", Input: "judge", Expect: evalExpect{Judge: "is a haiku"}},
+		{Name: "judge fail", Input: "exact", Expect: evalExpect{Judge: "is a haiku"}},
+		{Name: "error", Input: "error", Expect: evalExpect{Exact: "x"}},
+	}}
+
+	results := runEval(context.Background(), suite, "judge", 3, complete)
+	require.Len(t, results, len(suite.Cases))
+
+	byName := map[string]evalResult{}
+	for i, r := range results {
+		require.Equal(t, suite.Cases[i].Name, r.Name)
+		byName[r.Name] = r
+	}
+	require.True(t, byName["exact"].Pass)
+	require.False(t, byName["exact fail"].Pass)
+	require.Contains(t, byName["exact fail"].Details, "-hello there")
+	require.Contains(t, byName["exact fail"].Details, "+hello world")
+	require.True(t, byName["contains"].Pass)
+	require.False(t, byName["contains fail"].Pass)
+	require.Equal(t, "the answer is 42", byName["contains fail"].Details)
+	require.True(t, byName["regex"].Pass)
+	require.True(t, byName["judge"].Pass)
+	require.Equal(t, "it is a haiku", byName["judge"].Reason)
+	require.False(t, byName["judge fail"].Pass)
+	require.Equal(t, "not a haiku", byName["judge fail"].Reason)
+	require.EqualError(t, byName["error"].Err, "boom")
+}
+
+func TestParseVerdict(t *testing.T) {
+	for verdict, expected := range map[string]bool{
+		"PASS":                 true,
+		"**Pass**\nlooks good": true,
+		"PASS: it does":        true,
+		"FAIL\nnope":           false,
+		"I think it's fine":    false,
+		"":                     false,
+	} {
+		t.Run(verdict, func(t *testing.T) {
+			pass, _ := parseVerdict(verdict)
+			require.Equal(t, expected, pass)
+		})
+	}
+}
+
+func TestIsEvalCmd(t *testing.T) {
+	dir := t.TempDir()
+	cases := filepath.Join(dir, "cases.yml")
+	require.NoError(t, os.WriteFile(cases, []byte("cases: []"), 0o600))
+
+	for args, is := range map[string]bool{
+		"":                                   false,
+		"eval":                               false,
+		"eval this code":                     false,
+		"eval " + dir:                        false,
+		"eval " + cases:                      true,
+		"eval -h":                            true,
+		"eval " + cases + " -j 2":            true,
+		"eval --parallel=2 " + cases:         true,
+		"eval --judge-model gpt-4o " + cases: true,
+		"eval " + cases + " " + cases:        false,
+		"eval " + cases + " --raw":           false,
+		"eval nope.yml":                      false,
+	} {
+		t.Run(args, func(t *testing.T) {
+			require.Equal(t, is, isEvalCmd(append([]string{"mods"}, strings.Fields(args)...)))
+		})
+	}
+}
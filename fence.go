@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/exp/ordered"
+)
+
+const (
+	fenceNever  = "never"
+	fenceAuto   = "auto"
+	fenceAlways = "always"
+)
+
+var fenceModes = []string{fenceNever, fenceAuto, fenceAlways}
+
+// fenceInput wraps the input in a fenced code block, so the model doesn't
+// mistake code for prose.
+//
+// With the auto mode, the input is only fenced if it looks like code, or if
+// a language is given.
+func fenceInput(mode, lang, input string) string {
+	if strings.TrimSpace(input) == "" {
+		return input
+	}
+	switch mode {
+	case fenceAlways:
+	case fenceAuto:
+		if lang == "" && detectLang(input) == "" {
+			return input
+		}
+	default:
+		return input
+	}
+
+	lang = ordered.First(lang, detectLang(input))
+	fence := "```"
+	for strings.Contains(input, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + strings.TrimRight(input, "\n") + "\n" + fence
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFenceInput(t *testing.T) {
+	const code = "package main\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n"
+	const prose = "the quick brown fox jumps over the lazy dog"
+
+	for name, tc := range map[string]struct {
+		mode, lang, input, expected string
+	}{
+		"never": {
+			mode:     fenceNever,
+			input:    code,
+			expected: code,
+		},
+		"unset": {
+			input:    code,
+			expected: code,
+		},
+		"always detects the language": {
+			mode:     fenceAlways,
+			input:    code,
+			expected: "```go\n" + code + "```",
+		},
+		"always without language": {
+			mode:     fenceAlways,
+			input:    prose,
+			expected: "```\n" + prose + "\n```",
+		},
+		"always with language": {
+			mode:     fenceAlways,
+			lang:     "golang",
+			input:    code,
+			expected: "```golang\n" + code + "```",
+		},
+		"auto with code": {
+			mode:     fenceAuto,
+			input:    code,
+			expected: "```go\n" + code + "```",
+		},
+		"auto with prose": {
+			mode:     fenceAuto,
+			input:    prose,
+			expected: prose,
+		},
+		"auto with language": {
+			mode:     fenceAuto,
+			lang:     "text",
+			input:    prose,
+			expected: "```text\n" + prose + "\n```",
+		},
+		"input with fences": {
+			mode:     fenceAlways,
+			lang:     "md",
+			input:    "# title\n\n```sh\nls\n```",
+			expected: "````md\n# title\n\n```sh\nls\n```\n````",
+		},
+		"empty": {
+			mode:     fenceAlways,
+			input:    "\n",
+			expected: "\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, fenceInput(tc.mode, tc.lang, tc.input))
+		})
+	}
+}
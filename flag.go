@@ -2,6 +2,7 @@ package main
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -87,3 +88,68 @@ func (d *durationFlag) String() string {
 func (*durationFlag) Type() string {
 	return "duration"
 }
+
+// newOptionalBoolFlag creates a bool flag that stays nil unless set, so
+// "not set" can be told apart from false.
+func newOptionalBoolFlag(p **bool) *optionalBoolFlag {
+	return &optionalBoolFlag{p}
+}
+
+type optionalBoolFlag struct {
+	p **bool
+}
+
+func (f *optionalBoolFlag) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		//nolint: wrapcheck
+		return err
+	}
+	*f.p = &v
+	return nil
+}
+
+func (f *optionalBoolFlag) String() string {
+	if *f.p == nil {
+		return ""
+	}
+	return strconv.FormatBool(**f.p)
+}
+
+func (*optionalBoolFlag) Type() string {
+	return "bool"
+}
+
+// newPenaltyFlag creates a flag for a frequency or presence penalty, which
+// stays nil unless set, and must be from -2.0 to 2.0.
+func newPenaltyFlag(p **float64) *penaltyFlag {
+	return &penaltyFlag{p}
+}
+
+type penaltyFlag struct {
+	p **float64
+}
+
+func (f *penaltyFlag) Set(s string) error {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		//nolint: wrapcheck
+		return err
+	}
+	if err := validatePenalty(&v); err != nil {
+		return err
+	}
+	*f.p = &v
+	return nil
+}
+
+func (f *penaltyFlag) String() string {
+	if *f.p == nil {
+		return ""
+	}
+	return strconv.FormatFloat(**f.p, 'f', -1, 64)
+}
+
+func (*penaltyFlag) Type() string {
+	return "float"
+}
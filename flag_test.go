@@ -54,3 +54,38 @@ func TestFlagParseError(t *testing.T) {
 		})
 	}
 }
+
+func TestOptionalBoolFlag(t *testing.T) {
+	var b *bool
+	f := newOptionalBoolFlag(&b)
+	require.Empty(t, f.String())
+
+	require.NoError(t, f.Set("false"))
+	require.NotNil(t, b)
+	require.False(t, *b)
+	require.Equal(t, "false", f.String())
+
+	require.NoError(t, f.Set("true"))
+	require.True(t, *b)
+
+	require.Error(t, f.Set("nope"))
+}
+
+func TestPenaltyFlag(t *testing.T) {
+	var p *float64
+	f := newPenaltyFlag(&p)
+	require.Empty(t, f.String())
+
+	require.NoError(t, f.Set("-1.5"))
+	require.NotNil(t, p)
+	require.Equal(t, -1.5, *p)
+	require.Equal(t, "-1.5", f.String())
+
+	require.NoError(t, f.Set("2"))
+	require.Equal(t, 2.0, *p)
+
+	require.Error(t, f.Set("2.1"))
+	require.Error(t, f.Set("-3"))
+	require.Error(t, f.Set("nope"))
+	require.Equal(t, 2.0, *p)
+}
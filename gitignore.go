@@ -0,0 +1,136 @@
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ignoreRule is a pattern of a .gitignore file.
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// gitignore has the rules of the .gitignore files in a directory tree, by
+// the directory they are in, relative to its root.
+type gitignore map[string][]ignoreRule
+
+// load reads the .gitignore file in the given directory, if any.
+func (g gitignore) load(root, dir string) {
+	bts, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(dir), ".gitignore"))
+	if err != nil {
+		return
+	}
+	g[dir] = parseGitignore(string(bts))
+}
+
+// ignored returns whether the given path, relative to the root and with
+// forward slashes, is ignored.
+//
+// As in git, the last matching rule wins, and the rules in the deeper
+// .gitignore files come last.
+func (g gitignore) ignored(rel string, isDir bool) bool {
+	var dirs []string
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+	}
+	dirs = append(dirs, ".")
+	slices.Reverse(dirs)
+
+	ignored := false
+	for _, dir := range dirs {
+		name := rel
+		if dir != "." {
+			name = strings.TrimPrefix(rel, dir+"/")
+		}
+		for _, rule := range g[dir] {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.re.MatchString(name) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// parseGitignore parses the rules of a .gitignore file.
+func parseGitignore(content string) []ignoreRule {
+	var rules []ignoreRule
+	for line := range strings.SplitSeq(content, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		// patterns with a slash, other than a trailing one, are relative to
+		// the .gitignore, the others match at any depth.
+		prefix := "^(?:.*/)?"
+		if strings.Contains(line, "/") {
+			prefix = "^"
+			line = strings.TrimPrefix(line, "/")
+		}
+		re, err := regexp.Compile(prefix + globRegexp(line) + "$")
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// globRegexp translates a gitignore glob to a regular expression.
+func globRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**"):
+			sb.WriteString("(?:/.*)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
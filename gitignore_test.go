@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitignore(t *testing.T) {
+	g := gitignore{
+		".": parseGitignore(`# build output
+/bin
+*.log
+!keep.log
+vendor/
+docs/**/*.png
+\#notes
+`),
+		"web": parseGitignore("node_modules/\n/dist\n"),
+	}
+	for _, tc := range []struct {
+		rel    string
+		isDir  bool
+		expect bool
+	}{
+		{"bin", true, true},
+		{"cmd/bin", true, false},
+		{"debug.log", false, true},
+		{"cmd/debug.log", false, true},
+		{"keep.log", false, false},
+		{"vendor", true, true},
+		{"vendor", false, false},
+		{"docs/a.png", false, true},
+		{"docs/img/a.png", false, true},
+		{"img/a.png", false, false},
+		{"#notes", false, true},
+		{"main.go", false, false},
+		{"web/node_modules", true, true},
+		{"web/app/node_modules", true, true},
+		{"web/dist", true, true},
+		{"web/app/dist", true, false},
+		{"dist", true, false},
+	} {
+		t.Run(tc.rel, func(t *testing.T) {
+			require.Equal(t, tc.expect, g.ignored(tc.rel, tc.isDir))
+		})
+	}
+}
+
+func TestGitignoreLoad(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.tmp\n"), 0o600))
+	g := gitignore{}
+	g.load(dir, ".")
+	g.load(dir, "missing")
+	require.True(t, g.ignored("a.tmp", false))
+	require.NotContains(t, g, "missing")
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// shellHistory returns the last n commands from the current shell's history
+// file, with secrets redacted.
+func shellHistory(n int) ([]string, error) {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	path, err := historyFile(shell)
+	if err != nil {
+		return nil, err
+	}
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read shell history: %w", err)
+	}
+	cmds := parseHistory(shell, string(bts))
+
+	// the last entry is usually this very mods invocation.
+	if len(cmds) > 0 && isModsCmd(cmds[len(cmds)-1]) {
+		cmds = cmds[:len(cmds)-1]
+	}
+	if len(cmds) > n {
+		cmds = cmds[len(cmds)-n:]
+	}
+	for i := range cmds {
+		cmds[i] = redactSecrets(cmds[i])
+	}
+	return cmds, nil
+}
+
+func historyFile(shell string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find the home directory: %w", err)
+	}
+	switch shell {
+	case "bash", "zsh":
+		if path := os.Getenv("HISTFILE"); path != "" {
+			return path, nil
+		}
+		if shell == "bash" {
+			return filepath.Join(home, ".bash_history"), nil
+		}
+		dir := os.Getenv("ZDOTDIR")
+		if dir == "" {
+			dir = home
+		}
+		return filepath.Join(dir, ".zsh_history"), nil
+	case "fish":
+		dir := os.Getenv("XDG_DATA_HOME")
+		if dir == "" {
+			dir = filepath.Join(home, ".local", "share")
+		}
+		return filepath.Join(dir, "fish", "fish_history"), nil
+	case ".", "":
+		return "", errors.New("could not detect your shell: $SHELL is not set")
+	default:
+		return "", fmt.Errorf("shell history is not supported for %s", shell)
+	}
+}
+
+var (
+	bashTimestampReg = regexp.MustCompile(`^#\d+$`)
+	zshExtendedReg   = regexp.MustCompile(`^: \d+:\d+;`)
+)
+
+// parseHistory parses the contents of the history file of the given shell
+// into a list of commands, oldest first.
+func parseHistory(shell, content string) []string {
+	var cmds []string
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	switch shell {
+	case "fish":
+		for _, line := range lines {
+			cmd, ok := strings.CutPrefix(line, "- cmd: ")
+			if !ok {
+				continue
+			}
+			cmd = strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(cmd)
+			cmds = append(cmds, cmd)
+		}
+	case "zsh":
+		var current string
+		for _, line := range lines {
+			if current == "" {
+				line = zshExtendedReg.ReplaceAllString(line, "")
+			}
+			// multiline commands end their lines with a backslash.
+			if cont, ok := strings.CutSuffix(line, `\`); ok {
+				current += cont + "\n"
+				continue
+			}
+			current += line
+			if strings.TrimSpace(current) != "" {
+				cmds = append(cmds, current)
+			}
+			current = ""
+		}
+	default:
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" || bashTimestampReg.MatchString(line) {
+				continue
+			}
+			cmds = append(cmds, line)
+		}
+	}
+	return cmds
+}
+
+func isModsCmd(cmd string) bool {
+	fields := strings.Fields(cmd)
+	return len(fields) > 0 && filepath.Base(fields[0]) == "mods"
+}
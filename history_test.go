@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHistory(t *testing.T) {
+	t.Run("bash", func(t *testing.T) {
+		content := "ls -la\n#1700000000\ngit status\n\ngo test ./...\n"
+		require.Equal(t, []string{"ls -la", "git status", "go test ./..."}, parseHistory("bash", content))
+	})
+
+	t.Run("zsh", func(t *testing.T) {
+		content := ": 1700000000:0;ls -la\n: 1700000001:0;echo foo \\\nbar\ngit status\n"
+		require.Equal(t, []string{"ls -la", "echo foo \nbar", "git status"}, parseHistory("zsh", content))
+	})
+
+	t.Run("fish", func(t *testing.T) {
+		content := "- cmd: ls -la\n  when: 1700000000\n- cmd: echo foo\\nbar\n  when: 1700000001\n  paths:\n    - foo\n"
+		require.Equal(t, []string{"ls -la", "echo foo\nbar"}, parseHistory("fish", content))
+	})
+}
+
+func TestShellHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	require.NoError(t, os.WriteFile(path, []byte("one\ntwo\nthree\nmods --shell-history=2 'what happened?'\n"), 0o600))
+	t.Setenv("SHELL", "/bin/bash")
+	t.Setenv("HISTFILE", path)
+
+	cmds, err := shellHistory(2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"two", "three"}, cmds)
+
+	t.Run("unsupported shell", func(t *testing.T) {
+		t.Setenv("SHELL", "/bin/tcsh")
+		_, err := shellHistory(2)
+		require.EqualError(t, err, "shell history is not supported for tcsh")
+	})
+}
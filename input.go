@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	inputFormatText = "text"
+	inputFormatJSON = "json"
+	inputFormatCSV  = "csv"
+	inputFormatYAML = "yaml"
+)
+
+var inputFormats = []string{inputFormatText, inputFormatJSON, inputFormatCSV, inputFormatYAML}
+
+// formatInput parses the input in the given format, and renders it as
+// markdown, which models understand better than raw data dumps.
+func formatInput(format, input string) (string, error) {
+	switch format {
+	case "", inputFormatText:
+		return input, nil
+	case inputFormatCSV:
+		records, err := csv.NewReader(strings.NewReader(input)).ReadAll()
+		if err != nil {
+			return "", fmt.Errorf("invalid csv: %w", err)
+		}
+		if len(records) == 0 {
+			return "", nil
+		}
+		return markdownTable(records[0], records[1:]), nil
+	case inputFormatJSON:
+		var v any
+		if err := json.Unmarshal([]byte(input), &v); err != nil {
+			return "", fmt.Errorf("invalid json: %w", err)
+		}
+		return renderValue(v), nil
+	case inputFormatYAML:
+		var v any
+		if err := yaml.Unmarshal([]byte(input), &v); err != nil {
+			return "", fmt.Errorf("invalid yaml: %w", err)
+		}
+		return renderValue(v), nil
+	default:
+		return "", fmt.Errorf("unknown input format %q, valid formats are: %s", format, strings.Join(inputFormats, ", "))
+	}
+}
+
+func renderValue(v any) string {
+	var sb strings.Builder
+	writeValue(&sb, v, 0)
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func writeValue(sb *strings.Builder, v any, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch v := v.(type) {
+	case map[string]any:
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			if isScalar(v[k]) {
+				fmt.Fprintf(sb, "%s- **%s**: %s\n", indent, k, scalar(v[k]))
+				continue
+			}
+			fmt.Fprintf(sb, "%s- **%s**:\n", indent, k)
+			writeValue(sb, v[k], depth+1)
+		}
+	case []any:
+		if header, rows, ok := tabular(v); ok && depth == 0 {
+			sb.WriteString(markdownTable(header, rows) + "\n")
+			return
+		}
+		for i, item := range v {
+			if isScalar(item) {
+				fmt.Fprintf(sb, "%s- %s\n", indent, scalar(item))
+				continue
+			}
+			fmt.Fprintf(sb, "%s- **%d**:\n", indent, i+1)
+			writeValue(sb, item, depth+1)
+		}
+	default:
+		fmt.Fprintf(sb, "%s%s\n", indent, scalar(v))
+	}
+}
+
+// tabular returns the header and rows if the given list is made of objects
+// with only scalar values, so it can be rendered as a table.
+func tabular(list []any) ([]string, [][]string, bool) {
+	if len(list) == 0 {
+		return nil, nil, false
+	}
+	keys := map[string]struct{}{}
+	for _, item := range list {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, nil, false
+		}
+		for k, v := range obj {
+			if !isScalar(v) {
+				return nil, nil, false
+			}
+			keys[k] = struct{}{}
+		}
+	}
+	header := slices.Sorted(maps.Keys(keys))
+	rows := make([][]string, 0, len(list))
+	for _, item := range list {
+		obj := item.(map[string]any)
+		row := make([]string, 0, len(header))
+		for _, k := range header {
+			if v, ok := obj[k]; ok {
+				row = append(row, scalar(v))
+			} else {
+				row = append(row, "")
+			}
+		}
+		rows = append(rows, row)
+	}
+	return header, rows, true
+}
+
+func isScalar(v any) bool {
+	switch v.(type) {
+	case map[string]any, []any:
+		return false
+	default:
+		return true
+	}
+}
+
+func scalar(v any) string {
+	if v == nil {
+		return "null"
+	}
+	return fmt.Sprint(v)
+}
+
+func markdownTable(header []string, rows [][]string) string {
+	cell := strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ")
+	line := func(cells []string) string {
+		escaped := make([]string, len(header))
+		for i := range header {
+			if i < len(cells) {
+				escaped[i] = cell.Replace(cells[i])
+			}
+		}
+		return "| " + strings.Join(escaped, " | ") + " |"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(line(header) + "\n")
+	sb.WriteString("|" + strings.Repeat(" --- |", len(header)))
+	for _, row := range rows {
+		sb.WriteString("\n" + line(row))
+	}
+	return sb.String()
+}
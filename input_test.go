@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatInput(t *testing.T) {
+	t.Run("text", func(t *testing.T) {
+		out, err := formatInput(inputFormatText, "a,b\n1,2")
+		require.NoError(t, err)
+		require.Equal(t, "a,b\n1,2", out)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		out, err := formatInput(inputFormatCSV, "name,notes\nfoo,\"a|b\"\nbar,baz\n")
+		require.NoError(t, err)
+		require.Equal(t, "| name | notes |\n| --- | --- |\n| foo | a\\|b |\n| bar | baz |", out)
+	})
+
+	t.Run("malformed csv", func(t *testing.T) {
+		_, err := formatInput(inputFormatCSV, "a,b\n1,2,3\n")
+		require.ErrorContains(t, err, "invalid csv")
+	})
+
+	t.Run("json object", func(t *testing.T) {
+		out, err := formatInput(inputFormatJSON, `{"name":"mods","tags":["cli","ai"],"owner":{"name":"charm"}}`)
+		require.NoError(t, err)
+		require.Equal(t, "- **name**: mods\n- **owner**:\n  - **name**: charm\n- **tags**:\n  - cli\n  - ai", out)
+	})
+
+	t.Run("json table", func(t *testing.T) {
+		out, err := formatInput(inputFormatJSON, `[{"id":1,"name":"foo"},{"id":2}]`)
+		require.NoError(t, err)
+		require.Equal(t, "| id | name |\n| --- | --- |\n| 1 | foo |\n| 2 |  |", out)
+	})
+
+	t.Run("malformed json", func(t *testing.T) {
+		_, err := formatInput(inputFormatJSON, `{"name":`)
+		require.ErrorContains(t, err, "invalid json")
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		out, err := formatInput(inputFormatYAML, "name: mods\nitems:\n  - a: 1\n    b: [x]\n")
+		require.NoError(t, err)
+		require.Equal(t, "- **items**:\n  - **1**:\n    - **a**: 1\n    - **b**:\n      - x\n- **name**: mods", out)
+	})
+
+	t.Run("malformed yaml", func(t *testing.T) {
+		_, err := formatInput(inputFormatYAML, "name: [mods\n")
+		require.ErrorContains(t, err, "invalid yaml")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := formatInput("xml", "<a/>")
+		require.Error(t, err)
+	})
+}
@@ -3,6 +3,7 @@ package anthropic
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 	"strings"
 
@@ -22,6 +23,7 @@ type Client struct {
 
 // Request implements stream.Client.
 func (c *Client) Request(ctx context.Context, request proto.Request) stream.Stream {
+	slog.Debug("sending request", "api", "anthropic", "model", request.Model, "messages", len(request.Messages))
 	system, messages := fromProtoMessages(request.Messages)
 	body := anthropic.MessageNewParams{
 		Model:         anthropic.Model(request.Model),
@@ -2,9 +2,11 @@
 package cache
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 )
@@ -26,6 +28,11 @@ var errInvalidID = errors.New("invalid id")
 type Cache[T any] struct {
 	baseDir string
 	cType   Type
+
+	// version, if set, is written at the start of each entry, and entries
+	// with a different version are treated as missing.
+	// Entries without a version are still read.
+	version string
 }
 
 // New creates a new cache instance with the specified base directory and cache type.
@@ -48,15 +55,32 @@ func (c *Cache[T]) Read(id string, readFn func(io.Reader) error) error {
 	if id == "" {
 		return fmt.Errorf("read: %w", errInvalidID)
 	}
-	file, err := os.Open(filepath.Join(c.dir(), id+cacheExt))
+	path := filepath.Join(c.dir(), id+cacheExt)
+	file, err := os.Open(path)
 	if err != nil {
+		slog.Debug("cache miss", "type", c.cType, "id", id, "err", err)
 		return fmt.Errorf("read: %w", err)
 	}
 	defer file.Close() //nolint:errcheck
 
-	if err := readFn(file); err != nil {
+	var r io.Reader = file
+	if c.version != "" {
+		br := bufio.NewReader(file)
+		if err := checkVersion(br, c.version, true); err != nil {
+			if errors.Is(err, errVersionMismatch) {
+				slog.Info("removing stale cache entry", "type", c.cType, "id", id)
+				_ = file.Close()
+				_ = os.Remove(path)
+			}
+			return fmt.Errorf("read: %w", err)
+		}
+		r = br
+	}
+
+	if err := readFn(r); err != nil {
 		return fmt.Errorf("read: %w", err)
 	}
+	slog.Debug("cache hit", "type", c.cType, "id", id)
 	return nil
 }
 
@@ -71,6 +95,12 @@ func (c *Cache[T]) Write(id string, writeFn func(io.Writer) error) error {
 	}
 	defer file.Close() //nolint:errcheck
 
+	if c.version != "" {
+		if err := writeVersion(file, c.version); err != nil {
+			return fmt.Errorf("write: %w", err)
+		}
+	}
+
 	if err := writeFn(file); err != nil {
 		return fmt.Errorf("write: %w", err)
 	}
@@ -3,6 +3,7 @@ package cache
 import (
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -151,3 +152,99 @@ func TestExpiringCache(t *testing.T) {
 		require.Equal(t, data2, result)
 	})
 }
+
+func TestCacheVersion(t *testing.T) {
+	messages := []proto.Message{
+		{
+			Role:    proto.RoleUser,
+			Content: "first 4 natural numbers",
+		},
+	}
+
+	t.Run("conversation version mismatch", func(t *testing.T) {
+		dir := t.TempDir()
+		cache, err := NewConversations(dir)
+		require.NoError(t, err)
+		require.NoError(t, cache.Write("fake", &messages))
+
+		older, err := NewConversations(dir)
+		require.NoError(t, err)
+		older.cache.version = "0"
+		err = older.Read("fake", &[]proto.Message{})
+		require.ErrorIs(t, err, os.ErrNotExist)
+		require.ErrorIs(t, err, errVersionMismatch)
+
+		// the stale entry is removed.
+		require.ErrorIs(t, cache.Read("fake", &[]proto.Message{}), os.ErrNotExist)
+	})
+
+	t.Run("untagged conversation", func(t *testing.T) {
+		dir := t.TempDir()
+		untagged, err := New[[]proto.Message](dir, ConversationCache)
+		require.NoError(t, err)
+		require.NoError(t, untagged.Write("fake", func(w io.Writer) error {
+			return encode(w, &messages)
+		}))
+
+		cache, err := NewConversations(dir)
+		require.NoError(t, err)
+		result := []proto.Message{}
+		require.NoError(t, cache.Read("fake", &result))
+		require.ElementsMatch(t, messages, result)
+	})
+
+	type tokenV1 struct {
+		Token string `json:"token"`
+	}
+	type tokenV2 struct {
+		Token     string `json:"token"`
+		ExpiresAt int64  `json:"expires_at"`
+	}
+	expiresAt := time.Now().Add(time.Hour).Unix()
+	write := func(t *testing.T, w io.Writer) error {
+		t.Helper()
+		_, err := io.WriteString(w, `{"token":"foo"}`)
+		return err
+	}
+
+	t.Run("expiring version mismatch", func(t *testing.T) {
+		dir := t.TempDir()
+		v1, err := NewExpiring[tokenV1](dir)
+		require.NoError(t, err)
+		require.NoError(t, v1.Write("test", expiresAt, func(w io.Writer) error {
+			return write(t, w)
+		}))
+
+		v2, err := NewExpiring[tokenV2](dir)
+		require.NoError(t, err)
+		err = v2.Read("test", func(io.Reader) error {
+			require.FailNow(t, "should not decode a stale entry")
+			return nil
+		})
+		require.ErrorIs(t, err, os.ErrNotExist)
+
+		// the stale entry is removed.
+		matches, err := filepath.Glob(filepath.Join(v1.cache.dir(), "test.*"))
+		require.NoError(t, err)
+		require.Empty(t, matches)
+	})
+
+	t.Run("untagged expiring", func(t *testing.T) {
+		dir := t.TempDir()
+		cache, err := NewExpiring[tokenV1](dir)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(
+			filepath.Join(cache.cache.dir(), cache.getCacheFilename("test", expiresAt)),
+			[]byte(`{"token":"foo"}`),
+			0o600,
+		))
+		err = cache.Read("test", func(io.Reader) error { return nil })
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("shape version", func(t *testing.T) {
+		require.Equal(t, shapeVersion[tokenV1](), shapeVersion[tokenV1]())
+		require.NotEqual(t, shapeVersion[tokenV1](), shapeVersion[tokenV2]())
+		require.NotEqual(t, shapeVersion[[]proto.Message](), shapeVersion[[]noCallMessage]())
+	})
+}
@@ -10,6 +10,11 @@ import (
 	"github.com/charmbracelet/mods/internal/proto"
 )
 
+// conversationsVersion is the schema version of the conversation cache.
+// Bump it when a change to [proto.Message] can't be decoded from the entries
+// written by previous versions.
+const conversationsVersion = "1"
+
 // Conversations is the conversation cache.
 type Conversations struct {
 	cache *Cache[[]proto.Message]
@@ -21,6 +26,7 @@ func NewConversations(dir string) (*Conversations, error) {
 	if err != nil {
 		return nil, err
 	}
+	cache.version = conversationsVersion
 	return &Conversations{
 		cache: cache,
 	}, nil
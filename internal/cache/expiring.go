@@ -1,8 +1,11 @@
 package cache
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -11,8 +14,12 @@ import (
 )
 
 // ExpiringCache is a cache implementation that supports expiration of cached items.
+//
+// Entries are tagged with a version derived from the shape of T, so entries
+// written by a version of mods with a different T are treated as expired.
 type ExpiringCache[T any] struct {
-	cache *Cache[T]
+	cache   *Cache[T]
+	version string
 }
 
 // NewExpiring creates a new cache instance that supports item expiration.
@@ -21,7 +28,10 @@ func NewExpiring[T any](path string) (*ExpiringCache[T], error) {
 	if err != nil {
 		return nil, fmt.Errorf("create expiring cache: %w", err)
 	}
-	return &ExpiringCache[T]{cache: cache}, nil
+	return &ExpiringCache[T]{
+		cache:   cache,
+		version: shapeVersion[T](),
+	}, nil
 }
 
 func (c *ExpiringCache[T]) getCacheFilename(id string, expiresAt int64) string {
@@ -36,6 +46,7 @@ func (c *ExpiringCache[T]) Read(id string, readFn func(io.Reader) error) error {
 	}
 
 	if len(matches) == 0 {
+		slog.Debug("cache miss", "type", TemporaryCache, "id", id)
 		return fmt.Errorf("item not found")
 	}
 
@@ -53,6 +64,7 @@ func (c *ExpiringCache[T]) Read(id string, readFn func(io.Reader) error) error {
 	}
 
 	if expiresAt < time.Now().Unix() {
+		slog.Debug("cache entry expired", "type", TemporaryCache, "id", id)
 		if err := os.Remove(matches[0]); err != nil {
 			return fmt.Errorf("failed to remove expired cache file: %w", err)
 		}
@@ -63,13 +75,25 @@ func (c *ExpiringCache[T]) Read(id string, readFn func(io.Reader) error) error {
 	if err != nil {
 		return fmt.Errorf("failed to open expiring cache file: %w", err)
 	}
+	br := bufio.NewReader(file)
+	if err := checkVersion(br, c.version, false); err != nil {
+		_ = file.Close()
+		if errors.Is(err, errVersionMismatch) {
+			slog.Info("removing stale cache entry", "type", TemporaryCache, "id", id)
+			if err := os.Remove(matches[0]); err != nil {
+				return fmt.Errorf("failed to remove stale cache file: %w", err)
+			}
+		}
+		return err
+	}
 	defer func() {
 		if cerr := file.Close(); cerr != nil {
 			err = cerr
 		}
 	}()
 
-	return readFn(file)
+	slog.Debug("cache hit", "type", TemporaryCache, "id", id)
+	return readFn(br)
 }
 
 func (c *ExpiringCache[T]) Write(id string, expiresAt int64, writeFn func(io.Writer) error) error {
@@ -92,6 +116,9 @@ func (c *ExpiringCache[T]) Write(id string, expiresAt int64, writeFn func(io.Wri
 		}
 	}()
 
+	if err := writeVersion(file, c.version); err != nil {
+		return err
+	}
 	return writeFn(file)
 }
 
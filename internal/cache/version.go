@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// versionHeader prefixes the first line of versioned cache entries.
+const versionHeader = "mods-cache:"
+
+// errVersionMismatch is returned when a cache entry was written with a
+// different schema version. It is treated as a cache miss.
+var errVersionMismatch = fmt.Errorf("cache version mismatch: %w", os.ErrNotExist)
+
+// writeVersion writes the version header.
+func writeVersion(w io.Writer, version string) error {
+	if _, err := io.WriteString(w, versionHeader+version+"\n"); err != nil {
+		return fmt.Errorf("write version: %w", err)
+	}
+	return nil
+}
+
+// checkVersion reads the version header from the given reader, if any, and
+// returns errVersionMismatch if it does not match the wanted version.
+//
+// Entries without a header were written by older versions of mods, and are
+// only accepted if allowUntagged is true.
+func checkVersion(r *bufio.Reader, want string, allowUntagged bool) error {
+	prefix, err := r.Peek(len(versionHeader))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("read version: %w", err)
+	}
+	if string(prefix) != versionHeader {
+		if allowUntagged {
+			return nil
+		}
+		return errVersionMismatch
+	}
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("read version: %w", err)
+	}
+	got := strings.TrimSuffix(strings.TrimPrefix(line, versionHeader), "\n")
+	if got != want {
+		return errVersionMismatch
+	}
+	return nil
+}
+
+// shapeVersion returns a version derived from the shape of T, so it changes
+// whenever one of its fields is added, removed, renamed, or changes type.
+func shapeVersion[T any]() string {
+	var sb strings.Builder
+	describeType(&sb, reflect.TypeFor[T](), map[reflect.Type]bool{})
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(sb.String()))
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+func describeType(sb *strings.Builder, t reflect.Type, seen map[reflect.Type]bool) {
+	switch t.Kind() { //nolint:exhaustive
+	case reflect.Pointer:
+		sb.WriteString("*")
+		describeType(sb, t.Elem(), seen)
+	case reflect.Slice:
+		sb.WriteString("[]")
+		describeType(sb, t.Elem(), seen)
+	case reflect.Array:
+		fmt.Fprintf(sb, "[%d]", t.Len())
+		describeType(sb, t.Elem(), seen)
+	case reflect.Map:
+		sb.WriteString("map[")
+		describeType(sb, t.Key(), seen)
+		sb.WriteString("]")
+		describeType(sb, t.Elem(), seen)
+	case reflect.Struct:
+		if seen[t] {
+			sb.WriteString(t.String())
+			return
+		}
+		seen[t] = true
+		sb.WriteString("struct{")
+		for i := range t.NumField() {
+			f := t.Field(i)
+			fmt.Fprintf(sb, "%s %q ", f.Name, f.Tag)
+			describeType(sb, f.Type, seen)
+			sb.WriteString(";")
+		}
+		sb.WriteString("}")
+	default:
+		sb.WriteString(t.Kind().String())
+	}
+}
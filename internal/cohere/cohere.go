@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 
 	"github.com/charmbracelet/mods/internal/proto"
@@ -57,6 +58,7 @@ func New(config Config) *Client {
 
 // Request implements stream.Client.
 func (c *Client) Request(ctx context.Context, request proto.Request) stream.Stream {
+	slog.Debug("sending request", "api", "cohere", "model", request.Model, "messages", len(request.Messages))
 	s := &Stream{}
 	history, message := fromProtoMessages(request.Messages)
 	body := &cohere.ChatStreamRequest{
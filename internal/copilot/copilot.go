@@ -20,6 +20,7 @@ const (
 	copilotChatAuthURL   = "https://api.github.com/copilot_internal/v2/token"
 	copilotEditorVersion = "vscode/1.95.3"
 	copilotUserAgent     = "curl/7.81.0" // Necessay to bypass the user-agent check
+	cacheID              = "copilot"     // ID of the access token in the cache
 )
 
 // AccessToken response from GitHub Copilot's token endpoint.
@@ -48,9 +49,15 @@ type Client struct {
 }
 
 // New new copilot client.
-func New(cacheDir string) *Client {
+//
+// The given HTTP client, if any, is used both to fetch the access token and
+// to do the requests.
+func New(cacheDir string, client *http.Client) *Client {
+	if client == nil {
+		client = &http.Client{}
+	}
 	return &Client{
-		client: &http.Client{},
+		client: client,
 		cache:  cacheDir,
 	}
 }
@@ -134,15 +141,34 @@ func extractCopilotTokenFromFile(path string) (string, error) {
 
 // Auth authenticates the user and retrieves an access token.
 func (c *Client) Auth() (AccessToken, error) {
+	token, err := c.Cached()
+	if err == nil && token.ExpiresAt > time.Now().Unix() {
+		return token, nil
+	}
+	return c.Refresh()
+}
+
+// Cached returns the cached access token, if any and not expired.
+func (c *Client) Cached() (AccessToken, error) {
 	cache, err := cache.NewExpiring[AccessToken](c.cache)
-	if err == nil {
-		var token AccessToken
-		err = cache.Read("copilot", func(r io.Reader) error {
-			return json.NewDecoder(r).Decode(&token)
-		})
-		if err == nil && token.ExpiresAt > time.Now().Unix() {
-			return token, nil
-		}
+	if err != nil {
+		return AccessToken{}, fmt.Errorf("failed to open cache: %w", err)
+	}
+	var token AccessToken
+	if err := cache.Read(cacheID, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&token)
+	}); err != nil {
+		return AccessToken{}, fmt.Errorf("failed to read cached token: %w", err)
+	}
+	return token, nil
+}
+
+// Refresh retrieves a new access token, and caches it, replacing the
+// previous one.
+func (c *Client) Refresh() (AccessToken, error) {
+	cache, err := cache.NewExpiring[AccessToken](c.cache)
+	if err != nil {
+		return AccessToken{}, fmt.Errorf("failed to open cache: %w", err)
 	}
 
 	refreshToken, err := getCopilotRefreshToken()
@@ -179,12 +205,10 @@ func (c *Client) Auth() (AccessToken, error) {
 		return AccessToken{}, fmt.Errorf("token error: %s", tokenResponse.ErrorDetails.Message)
 	}
 
-	if cache != nil {
-		if err := cache.Write("copilot", tokenResponse.ExpiresAt, func(w io.Writer) error {
-			return json.NewEncoder(w).Encode(tokenResponse)
-		}); err != nil {
-			return AccessToken{}, fmt.Errorf("failed to cache token: %w", err)
-		}
+	if err := cache.Write(cacheID, tokenResponse.ExpiresAt, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(tokenResponse)
+	}); err != nil {
+		return AccessToken{}, fmt.Errorf("failed to cache token: %w", err)
 	}
 
 	return tokenResponse, nil
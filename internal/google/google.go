@@ -5,15 +5,14 @@ import (
 	"bufio"
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 
 	"github.com/charmbracelet/mods/internal/proto"
 	"github.com/charmbracelet/mods/internal/stream"
-	"github.com/openai/openai-go"
 )
 
 var _ stream.Client = &Client{}
@@ -95,6 +94,7 @@ type Client struct {
 
 // Request implements stream.Client.
 func (c *Client) Request(ctx context.Context, request proto.Request) stream.Stream {
+	slog.Debug("sending request", "api", "google", "model", request.Model, "messages", len(request.Messages))
 	stream := new(Stream)
 	body := MessageCompletionRequest{
 		Contents: fromProtoMessages(request.Messages),
@@ -164,16 +164,14 @@ func (c *Client) newRequest(ctx context.Context, method, url string, setters ...
 }
 
 func (c *Client) handleErrorResp(resp *http.Response) error {
-	// Print the response text
-	var errRes openai.Error
-	if err := json.NewDecoder(resp.Body).Decode(&errRes); err != nil {
-		return &openai.Error{
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &proto.ProviderError{
 			StatusCode: resp.StatusCode,
 			Message:    err.Error(),
 		}
 	}
-	errRes.StatusCode = resp.StatusCode
-	return &errRes
+	return proto.NewProviderError(resp.StatusCode, body)
 }
 
 // Candidate represents a response candidate generated from the model.
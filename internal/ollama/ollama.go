@@ -3,6 +3,7 @@ package ollama
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -48,6 +49,7 @@ func New(config Config) (*Client, error) {
 
 // Request implements stream.Client.
 func (c *Client) Request(ctx context.Context, request proto.Request) stream.Stream {
+	slog.Debug("sending request", "api", "ollama", "model", request.Model, "messages", len(request.Messages))
 	b := true
 	s := &Stream{
 		toolCall: request.ToolCaller,
@@ -72,6 +74,12 @@ func (c *Client) Request(ctx context.Context, request proto.Request) stream.Stre
 	if request.TopP != nil {
 		body.Options["top_p"] = *request.TopP
 	}
+	if request.FrequencyPenalty != nil {
+		body.Options["frequency_penalty"] = *request.FrequencyPenalty
+	}
+	if request.PresencePenalty != nil {
+		body.Options["presence_penalty"] = *request.PresencePenalty
+	}
 	s.request = body
 	s.messages = request.Messages
 	s.factory = func() {
@@ -34,11 +34,15 @@ func fromMCPTools(mcps map[string][]mcp.Tool) []openai.ChatCompletionToolParam {
 	return tools
 }
 
-func fromProtoMessages(input []proto.Message) []openai.ChatCompletionMessageParamUnion {
+func fromProtoMessages(input []proto.Message, systemRole string) []openai.ChatCompletionMessageParamUnion {
 	var messages []openai.ChatCompletionMessageParamUnion
 	for _, msg := range input {
 		switch msg.Role {
 		case proto.RoleSystem:
+			if systemRole == proto.RoleDeveloper {
+				messages = append(messages, openai.DeveloperMessage(msg.Content))
+				break
+			}
 			messages = append(messages, openai.SystemMessage(msg.Content))
 		case proto.RoleTool:
 			for _, call := range msg.ToolCalls {
@@ -97,7 +101,7 @@ func toProtoMessage(in openai.ChatCompletionMessageParamUnion) proto.Message {
 }
 
 func msgRole(in openai.ChatCompletionMessageParamUnion) string {
-	if in.OfSystem != nil {
+	if in.OfSystem != nil || in.OfDeveloper != nil {
 		return proto.RoleSystem
 	}
 	if in.OfAssistant != nil {
@@ -2,7 +2,11 @@
 package openai
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
 	"strings"
 
@@ -29,7 +33,9 @@ type Config struct {
 	HTTPClient interface {
 		Do(*http.Request) (*http.Response, error)
 	}
-	APIType string
+	APIType      string
+	Organization string
+	Project      string
 }
 
 // DefaultConfig returns the default configuration for the OpenAI API client.
@@ -58,6 +64,12 @@ func New(config Config) *Client {
 			opts = append(opts, option.WithBaseURL(config.BaseURL))
 		}
 	}
+	if config.Organization != "" {
+		opts = append(opts, option.WithOrganization(config.Organization))
+	}
+	if config.Project != "" {
+		opts = append(opts, option.WithProject(config.Project))
+	}
 	client := openai.NewClient(opts...)
 	return &Client{
 		Client: &client,
@@ -66,13 +78,18 @@ func New(config Config) *Client {
 
 // Request makes a new request and returns a stream.
 func (c *Client) Request(ctx context.Context, request proto.Request) stream.Stream {
+	slog.Debug("sending request", "api", request.API, "model", request.Model, "messages", len(request.Messages))
 	body := openai.ChatCompletionNewParams{
 		Model:    request.Model,
 		User:     openai.String(request.User),
-		Messages: fromProtoMessages(request.Messages),
+		Messages: fromProtoMessages(request.Messages, request.SystemRole),
 		Tools:    fromMCPTools(request.Tools),
 	}
 
+	if request.ParallelToolCalls != nil && len(body.Tools) > 0 {
+		body.ParallelToolCalls = openai.Bool(*request.ParallelToolCalls)
+	}
+
 	if request.API != "perplexity" || !strings.Contains(request.Model, "online") {
 		if request.Temperature != nil {
 			body.Temperature = openai.Float(*request.Temperature)
@@ -80,6 +97,12 @@ func (c *Client) Request(ctx context.Context, request proto.Request) stream.Stre
 		if request.TopP != nil {
 			body.TopP = openai.Float(*request.TopP)
 		}
+		if request.FrequencyPenalty != nil {
+			body.FrequencyPenalty = openai.Float(*request.FrequencyPenalty)
+		}
+		if request.PresencePenalty != nil {
+			body.PresencePenalty = openai.Float(*request.PresencePenalty)
+		}
 		body.Stop = openai.ChatCompletionNewParamsStopUnion{
 			OfStringArray: request.Stop,
 		}
@@ -154,7 +177,18 @@ func (s *Stream) Current() (proto.Chunk, error) {
 }
 
 // Err implements stream.Stream.
-func (s *Stream) Err() error { return s.stream.Err() } //nolint:wrapcheck
+func (s *Stream) Err() error {
+	err := s.stream.Err()
+	var aerr *openai.Error
+	if !errors.As(err, &aerr) || aerr.Response == nil {
+		return err //nolint:wrapcheck
+	}
+	// the error body is not always in the format the SDK expects, so it is
+	// parsed again.
+	body, _ := io.ReadAll(aerr.Response.Body)
+	aerr.Response.Body = io.NopCloser(bytes.NewReader(body))
+	return proto.NewProviderError(aerr.StatusCode, body)
+}
 
 // Messages implements stream.Stream.
 func (s *Stream) Messages() []proto.Message { return s.messages }
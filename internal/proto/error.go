@@ -0,0 +1,128 @@
+package proto
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ProviderError is an error returned by a provider's API, e.g. a 400 because
+// max_tokens is too large for the model.
+type ProviderError struct {
+	StatusCode int
+	Message    string
+	Type       string
+	Code       string
+	Param      string
+}
+
+func (e *ProviderError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		sb.WriteString(": " + e.Message)
+	}
+	var details []string
+	if e.Type != "" {
+		details = append(details, "type: "+e.Type)
+	}
+	if e.Code != "" && e.Code != strconv.Itoa(e.StatusCode) {
+		details = append(details, "code: "+e.Code)
+	}
+	if e.Param != "" {
+		details = append(details, "param: "+e.Param)
+	}
+	if len(details) > 0 {
+		sb.WriteString(" (" + strings.Join(details, ", ") + ")")
+	}
+	return sb.String()
+}
+
+// providerErrorBody is the error object, as sent by OpenAI and most
+// compatible APIs.
+type providerErrorBody struct {
+	Message string          `json:"message"`
+	Type    string          `json:"type"`
+	Status  string          `json:"status"`
+	Code    json.RawMessage `json:"code"`
+	Param   json.RawMessage `json:"param"`
+	Detail  json.RawMessage `json:"detail"`
+}
+
+// NewProviderError parses the given error response body.
+//
+// It understands the usual `{"error": {"message", "type", "code"}}` envelope,
+// a list of those, `{"error": "message"}`, and an error object without the
+// envelope. Anything else is used as the message as is.
+func NewProviderError(statusCode int, body []byte) *ProviderError {
+	perr := &ProviderError{StatusCode: statusCode}
+	body = bytes.TrimSpace(body)
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(body, &list); err == nil {
+		if len(list) == 0 {
+			return perr
+		}
+		return NewProviderError(statusCode, list[0])
+	}
+
+	var envelope struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		perr.Message = string(body)
+		return perr
+	}
+
+	errBody := body
+	if len(envelope.Error) > 0 {
+		var msg string
+		if err := json.Unmarshal(envelope.Error, &msg); err == nil {
+			perr.Message = msg
+			return perr
+		}
+		errBody = envelope.Error
+	}
+
+	var eb providerErrorBody
+	if err := json.Unmarshal(errBody, &eb); err != nil {
+		perr.Message = string(body)
+		return perr
+	}
+	perr.Message = strings.TrimSpace(eb.Message)
+	if perr.Message == "" {
+		perr.Message = rawString(eb.Detail)
+	}
+	if perr.Message == "" {
+		perr.Message = string(body)
+	}
+	perr.Type = eb.Type
+	if perr.Type == "" {
+		perr.Type = eb.Status
+	}
+	perr.Code = rawString(eb.Code)
+	perr.Param = rawString(eb.Param)
+	return perr
+}
+
+// rawString returns the given JSON string or number as a string, and
+// anything else as its JSON, ignoring nulls.
+func rawString(raw json.RawMessage) string {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return ""
+	}
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return string(raw)
+	}
+}
@@ -0,0 +1,128 @@
+package proto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProviderError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		status int
+		body   string
+		expect ProviderError
+		err    string
+	}{
+		"openai": {
+			status: 400,
+			body:   `{"error":{"message":"max_tokens is too large: 100000. This model supports at most 16384 completion tokens, whereas you provided 100000.","type":"invalid_request_error","param":"max_tokens","code":"invalid_value"}}`,
+			expect: ProviderError{
+				StatusCode: 400,
+				Message:    "max_tokens is too large: 100000. This model supports at most 16384 completion tokens, whereas you provided 100000.",
+				Type:       "invalid_request_error",
+				Code:       "invalid_value",
+				Param:      "max_tokens",
+			},
+			err: "400 Bad Request: max_tokens is too large: 100000. This model supports at most 16384 completion tokens, whereas you provided 100000. (type: invalid_request_error, code: invalid_value, param: max_tokens)",
+		},
+		"openai context length": {
+			status: 400,
+			body:   `{"error":{"message":"This model's maximum context length is 128000 tokens. However, your messages resulted in 130000 tokens. Please reduce the length of the messages.","type":"invalid_request_error","param":"messages","code":"context_length_exceeded"}}`,
+			expect: ProviderError{
+				StatusCode: 400,
+				Message:    "This model's maximum context length is 128000 tokens. However, your messages resulted in 130000 tokens. Please reduce the length of the messages.",
+				Type:       "invalid_request_error",
+				Code:       "context_length_exceeded",
+				Param:      "messages",
+			},
+		},
+		"groq": {
+			status: 404,
+			body:   `{"error":{"message":"The model ` + "`llama3-70b`" + ` does not exist or you do not have access to it.","type":"invalid_request_error","code":"model_not_found"}}`,
+			expect: ProviderError{
+				StatusCode: 404,
+				Message:    "The model `llama3-70b` does not exist or you do not have access to it.",
+				Type:       "invalid_request_error",
+				Code:       "model_not_found",
+			},
+		},
+		"mistral": {
+			status: 400,
+			body:   `{"object":"error","message":"Prompt contains 40000 tokens and 0 draft tokens, too large for model with 32768 maximum context length","type":"invalid_request_message_error","param":null,"code":null}`,
+			expect: ProviderError{
+				StatusCode: 400,
+				Message:    "Prompt contains 40000 tokens and 0 draft tokens, too large for model with 32768 maximum context length",
+				Type:       "invalid_request_message_error",
+			},
+			err: "400 Bad Request: Prompt contains 40000 tokens and 0 draft tokens, too large for model with 32768 maximum context length (type: invalid_request_message_error)",
+		},
+		"google": {
+			status: 400,
+			body:   `[{"error":{"code":400,"message":"API key not valid. Please pass a valid API key.","status":"INVALID_ARGUMENT"}}]`,
+			expect: ProviderError{
+				StatusCode: 400,
+				Message:    "API key not valid. Please pass a valid API key.",
+				Type:       "INVALID_ARGUMENT",
+				Code:       "400",
+			},
+			err: "400 Bad Request: API key not valid. Please pass a valid API key. (type: INVALID_ARGUMENT)",
+		},
+		"openrouter": {
+			status: 400,
+			body:   `{"error":{"message":"deepseek/deepseek-r2 is not a valid model ID","code":400},"user_id":"user_2abc"}`,
+			expect: ProviderError{
+				StatusCode: 400,
+				Message:    "deepseek/deepseek-r2 is not a valid model ID",
+				Code:       "400",
+			},
+		},
+		"llama.cpp": {
+			status: 400,
+			body:   `{"error":{"code":400,"message":"the request exceeds the available context size, try increasing it","type":"exceed_context_size_error","n_prompt_tokens":5000,"n_ctx":4096}}`,
+			expect: ProviderError{
+				StatusCode: 400,
+				Message:    "the request exceeds the available context size, try increasing it",
+				Type:       "exceed_context_size_error",
+				Code:       "400",
+			},
+		},
+		"string error": {
+			status: 400,
+			body:   `{"error":"invalid model name"}`,
+			expect: ProviderError{
+				StatusCode: 400,
+				Message:    "invalid model name",
+			},
+			err: "400 Bad Request: invalid model name",
+		},
+		"detail": {
+			status: 400,
+			body:   `{"detail":"Unsupported parameter: temperature"}`,
+			expect: ProviderError{
+				StatusCode: 400,
+				Message:    "Unsupported parameter: temperature",
+			},
+		},
+		"not json": {
+			status: 400,
+			body:   "Bad Request\n",
+			expect: ProviderError{
+				StatusCode: 400,
+				Message:    "Bad Request",
+			},
+		},
+		"empty": {
+			status: 400,
+			expect: ProviderError{StatusCode: 400},
+			err:    "400 Bad Request",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			perr := NewProviderError(tc.status, []byte(tc.body))
+			require.Equal(t, tc.expect, *perr)
+			if tc.err != "" {
+				require.Equal(t, tc.err, perr.Error())
+			}
+		})
+	}
+}
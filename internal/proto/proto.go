@@ -17,6 +17,11 @@ const (
 	RoleTool      = "tool"
 )
 
+// RoleDeveloper is what newer OpenAI models call the system role. Messages
+// always use [RoleSystem], and are sent as this if [Request.SystemRole] says
+// so.
+const RoleDeveloper = "developer"
+
 // Chunk is a streaming chunk of text.
 type Chunk struct {
 	Content string
@@ -76,12 +81,32 @@ type Request struct {
 	MaxTokens      *int64
 	ResponseFormat *string
 	ToolCaller     func(name string, data []byte) (string, error)
+
+	// ParallelToolCalls, if set, allows or forbids calling multiple tools at
+	// once. Only supported by OpenAI compatible APIs.
+	ParallelToolCalls *bool
+
+	// FrequencyPenalty and PresencePenalty, if set, penalize repeating tokens,
+	// from -2.0 to 2.0. Only supported by OpenAI compatible APIs and Ollama.
+	FrequencyPenalty *float64
+	PresencePenalty  *float64
+
+	// SystemRole is the name the system messages are sent with, either
+	// [RoleSystem], the default, or [RoleDeveloper]. Only supported by OpenAI
+	// compatible APIs.
+	SystemRole string
 }
 
 // Conversation is a conversation.
 type Conversation []Message
 
 func (cc Conversation) String() string {
+	return cc.WithAssistantLabel("Assistant")
+}
+
+// WithAssistantLabel returns the conversation as a string, using the given
+// label for the assistant messages.
+func (cc Conversation) WithAssistantLabel(label string) string {
 	var sb strings.Builder
 	for _, msg := range cc {
 		if msg.Content == "" {
@@ -104,7 +129,7 @@ func (cc Conversation) String() string {
 			}
 			continue
 		case RoleAssistant:
-			sb.WriteString("**Assistant**: ")
+			sb.WriteString("**" + label + "**: ")
 		}
 		sb.WriteString(msg.Content)
 		sb.WriteString("\n\n")
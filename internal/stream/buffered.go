@@ -0,0 +1,93 @@
+package stream
+
+import (
+	"sync"
+
+	"github.com/charmbracelet/mods/internal/proto"
+)
+
+// Buffered returns a [Stream] that reads the given one ahead, in the
+// background, holding at most size chunks that were not consumed yet.
+//
+// Once the buffer is full, reading from the given stream waits for the
+// consumer, so a slow consumer slows down the reads instead of the chunks
+// piling up in memory.
+func Buffered(s Stream, size int) Stream {
+	return &bufferedStream{
+		Stream: s,
+		size:   max(size, 1),
+		stop:   make(chan struct{}),
+	}
+}
+
+type bufferedChunk struct {
+	chunk proto.Chunk
+	err   error
+}
+
+type bufferedStream struct {
+	Stream
+	size    int
+	chunks  chan bufferedChunk
+	current bufferedChunk
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	reading  sync.WaitGroup
+}
+
+// Next implements Stream.
+//
+// The given stream is read until its Next returns false, so it's safe to call
+// CallTools, Err, and Messages once this returns false.
+func (s *bufferedStream) Next() bool {
+	if s.chunks == nil {
+		s.chunks = make(chan bufferedChunk, s.size)
+		s.reading.Add(1)
+		go s.read(s.chunks)
+	}
+	chunk, ok := <-s.chunks
+	if !ok {
+		s.chunks = nil
+		s.reading.Wait()
+		return false
+	}
+	s.current = chunk
+	return true
+}
+
+func (s *bufferedStream) read(chunks chan<- bufferedChunk) {
+	defer s.reading.Done()
+	defer close(chunks)
+	for {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+		if !s.Stream.Next() {
+			return
+		}
+		chunk, err := s.Stream.Current()
+		select {
+		case chunks <- bufferedChunk{chunk, err}:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Current implements Stream.
+func (s *bufferedStream) Current() (proto.Chunk, error) {
+	return s.current.chunk, s.current.err
+}
+
+// Close implements Stream.
+//
+// It waits for the read in progress, if any, to return before closing the
+// given stream, as it can't be used from two goroutines at once.
+func (s *bufferedStream) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	s.reading.Wait()
+	return s.Stream.Close() //nolint:wrapcheck
+}
@@ -0,0 +1,165 @@
+package stream
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/mods/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStream produces chunks as fast as they are read, and one more round of
+// them after its tools are called.
+type fakeStream struct {
+	chunks   int
+	rounds   int
+	produced atomic.Int64
+	current  int
+	closed   atomic.Bool
+}
+
+func (s *fakeStream) Next() bool {
+	if s.current >= s.chunks {
+		return false
+	}
+	s.current++
+	s.produced.Add(1)
+	return true
+}
+
+func (s *fakeStream) Current() (proto.Chunk, error) {
+	if s.current%10 == 0 {
+		return proto.Chunk{}, ErrNoContent
+	}
+	return proto.Chunk{Content: strconv.Itoa(s.current)}, nil
+}
+
+func (s *fakeStream) Close() error {
+	s.closed.Store(true)
+	return nil
+}
+
+func (s *fakeStream) Err() error                { return nil }
+func (s *fakeStream) Messages() []proto.Message { return nil }
+
+func (s *fakeStream) CallTools() []proto.ToolCallStatus {
+	s.rounds--
+	if s.rounds <= 0 {
+		return nil
+	}
+	s.current = 0
+	return []proto.ToolCallStatus{{Name: "tool"}}
+}
+
+// slowStream takes a while to produce each chunk, and fails the test if it's
+// closed while producing one.
+type slowStream struct {
+	fakeStream
+	t       *testing.T
+	reading atomic.Bool
+	pos     int
+}
+
+func (s *slowStream) Next() bool {
+	s.reading.Store(true)
+	defer s.reading.Store(false)
+	time.Sleep(5 * time.Millisecond)
+	s.pos++
+	return s.fakeStream.Next()
+}
+
+func (s *slowStream) Close() error {
+	if s.reading.Load() {
+		s.t.Error("closed while reading")
+	}
+	s.pos = -1
+	return s.fakeStream.Close()
+}
+
+func TestBuffered(t *testing.T) {
+	t.Run("slow consumer", func(t *testing.T) {
+		const size = 8
+		fake := &fakeStream{chunks: 200, rounds: 1}
+		s := Buffered(fake, size)
+
+		var consumed, maxAhead int64
+		for s.Next() {
+			consumed++
+			// the reader may hold one more chunk, waiting for room in the
+			// buffer.
+			ahead := fake.produced.Load() - consumed
+			maxAhead = max(maxAhead, ahead)
+			require.LessOrEqual(t, ahead, int64(size+1))
+			time.Sleep(100 * time.Microsecond)
+		}
+		require.Equal(t, int64(200), consumed)
+		require.Equal(t, int64(200), fake.produced.Load())
+		require.Positive(t, maxAhead, "never read ahead")
+	})
+
+	t.Run("chunks and errors in order", func(t *testing.T) {
+		s := Buffered(&fakeStream{chunks: 25, rounds: 1}, 4)
+		var contents []string
+		var noContent int
+		for s.Next() {
+			chunk, err := s.Current()
+			if errors.Is(err, ErrNoContent) {
+				noContent++
+				continue
+			}
+			require.NoError(t, err)
+			contents = append(contents, chunk.Content)
+		}
+		require.Equal(t, 2, noContent)
+		require.Len(t, contents, 23)
+		require.Equal(t, "1", contents[0])
+		require.Equal(t, "25", contents[22])
+	})
+
+	t.Run("reads again after tool calls", func(t *testing.T) {
+		s := Buffered(&fakeStream{chunks: 5, rounds: 2}, 2)
+		var total int
+		for {
+			for s.Next() {
+				total++
+			}
+			require.NoError(t, s.Err())
+			if len(s.CallTools()) == 0 {
+				break
+			}
+		}
+		require.Equal(t, 10, total)
+	})
+
+	t.Run("close stops the reader", func(t *testing.T) {
+		fake := &fakeStream{chunks: 1000, rounds: 1}
+		s := Buffered(fake, 2)
+		require.True(t, s.Next())
+		require.NoError(t, s.Close())
+		require.True(t, fake.closed.Load())
+
+		done := make(chan struct{})
+		go func() {
+			s.(*bufferedStream).reading.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("reader did not stop")
+		}
+		require.Less(t, fake.produced.Load(), int64(1000))
+	})
+
+	t.Run("close during a slow read", func(t *testing.T) {
+		slow := &slowStream{fakeStream: fakeStream{chunks: 1000, rounds: 1}, t: t}
+		s := Buffered(slow, 2)
+		require.True(t, s.Next())
+		require.NoError(t, s.Close())
+		require.True(t, slow.closed.Load())
+		require.Equal(t, -1, slow.pos)
+	})
+}
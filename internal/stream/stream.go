@@ -4,6 +4,7 @@ package stream
 import (
 	"context"
 	"errors"
+	"log/slog"
 
 	"github.com/charmbracelet/mods/internal/proto"
 )
@@ -47,7 +48,11 @@ func CallTool(
 	data []byte,
 	caller func(name string, data []byte) (string, error),
 ) (proto.Message, proto.ToolCallStatus) {
+	slog.Debug("calling tool", "name", name)
 	content, err := caller(name, data)
+	if err != nil {
+		slog.Debug("tool call failed", "name", name, "err", err)
+	}
 	if content == "" && err != nil {
 		content = err.Error()
 	}
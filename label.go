@@ -0,0 +1,49 @@
+package main
+
+import "github.com/charmbracelet/lipgloss"
+
+const (
+	assistantLabelModel = "model"
+	assistantLabelRole  = "role"
+)
+
+const defaultLabelColor = "#FF5FD2"
+
+// assistantLabel returns the label to show before the assistant messages, if
+// any.
+//
+// The label can be set to "model" or "role" to use the current model or role
+// name, or to any other text to use it as is.
+func (m *Mods) assistantLabel() string {
+	if m.Config.Quiet {
+		return ""
+	}
+	switch m.Config.AssistantLabel {
+	case assistantLabelModel:
+		return m.Config.Model
+	case assistantLabelRole:
+		if role := resolveRole(m.Config); role != "" {
+			return role
+		}
+		return m.Config.Model
+	default:
+		return m.Config.AssistantLabel
+	}
+}
+
+// assistantLabelView renders the assistant label as a header.
+func (m *Mods) assistantLabelView() string {
+	label := m.assistantLabel()
+	if label == "" {
+		return ""
+	}
+	color := m.Config.LabelColor
+	if color == "" {
+		color = defaultLabelColor
+	}
+	return m.renderer.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(color)).
+		Padding(1, 0, 0, 2). //nolint:mnd
+		Render(label)
+}
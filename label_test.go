@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssistantLabel(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cfg    Config
+		expect string
+	}{
+		"off":           {Config{Model: "gpt-4o"}, ""},
+		"model":         {Config{Model: "gpt-4o", AssistantLabel: "model"}, "gpt-4o"},
+		"role":          {Config{Model: "gpt-4o", Role: "shell", AssistantLabel: "role"}, "shell"},
+		"role fallback": {Config{Model: "gpt-4o", NoRole: true, AssistantLabel: "role"}, "gpt-4o"},
+		"text":          {Config{Model: "gpt-4o", AssistantLabel: "Jarvis"}, "Jarvis"},
+		"quiet":         {Config{Model: "gpt-4o", AssistantLabel: "model", Quiet: true}, ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := &Mods{Config: &tc.cfg}
+			require.Equal(t, tc.expect, m.assistantLabel())
+		})
+	}
+}
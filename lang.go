@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// languages maps the known language codes to their names.
+var languages = map[string]string{
+	"ar": "Arabic",
+	"bg": "Bulgarian",
+	"bn": "Bengali",
+	"ca": "Catalan",
+	"cs": "Czech",
+	"da": "Danish",
+	"de": "German",
+	"el": "Greek",
+	"en": "English",
+	"es": "Spanish",
+	"et": "Estonian",
+	"fa": "Persian",
+	"fi": "Finnish",
+	"fr": "French",
+	"he": "Hebrew",
+	"hi": "Hindi",
+	"hr": "Croatian",
+	"hu": "Hungarian",
+	"id": "Indonesian",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"lt": "Lithuanian",
+	"lv": "Latvian",
+	"ms": "Malay",
+	"nl": "Dutch",
+	"no": "Norwegian",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ro": "Romanian",
+	"ru": "Russian",
+	"sk": "Slovak",
+	"sl": "Slovenian",
+	"sr": "Serbian",
+	"sv": "Swedish",
+	"sw": "Swahili",
+	"th": "Thai",
+	"tl": "Filipino",
+	"tr": "Turkish",
+	"uk": "Ukrainian",
+	"ur": "Urdu",
+	"vi": "Vietnamese",
+	"zh": "Chinese",
+}
+
+// languageName returns the name of the language with the given code, e.g.
+// "fr" or "pt-BR".
+//
+// Unknown codes are used as is, so it's also possible to give the name of the
+// language directly.
+func languageName(code string) string {
+	code = strings.TrimSpace(code)
+	lang, region, _ := strings.Cut(strings.ReplaceAll(code, "_", "-"), "-")
+	name, ok := languages[strings.ToLower(lang)]
+	if !ok {
+		slog.Debug("unknown language code, using it as is", "lang", code)
+		return code
+	}
+	if region != "" {
+		return fmt.Sprintf("%s (%s)", name, strings.ToUpper(region))
+	}
+	return name
+}
+
+// languageInstruction returns the system message asking the model to answer
+// in the configured language, if any.
+func languageInstruction(cfg *Config) string {
+	if cfg.OutputLanguage == "" {
+		return ""
+	}
+	return fmt.Sprintf(
+		"Respond in %s, regardless of the language of the prompt.",
+		languageName(cfg.OutputLanguage),
+	)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/mods/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguageName(t *testing.T) {
+	for code, expect := range map[string]string{
+		"fr":      "French",
+		"FR":      "French",
+		"pt-BR":   "Portuguese (BR)",
+		"pt_br":   "Portuguese (BR)",
+		"Klingon": "Klingon",
+	} {
+		t.Run(code, func(t *testing.T) {
+			require.Equal(t, expect, languageName(code))
+		})
+	}
+}
+
+func TestLanguageInstruction(t *testing.T) {
+	m := &Mods{
+		Config: &Config{
+			Role:           "shell",
+			OutputLanguage: "fr",
+			NoLimit:        true,
+			Roles: map[string][]string{
+				"shell": {"you are a shell expert"},
+			},
+		},
+	}
+	require.NoError(t, m.setupStreamContext("hi", Model{}))
+	require.Equal(t, []proto.Message{
+		{Role: proto.RoleSystem, Content: "you are a shell expert"},
+		{Role: proto.RoleSystem, Content: "Respond in French, regardless of the language of the prompt."},
+		{Role: proto.RoleUser, Content: "hi"},
+	}, m.messages)
+}
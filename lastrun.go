@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// lastInvocation is the last command that sent a prompt, as stored for
+// --retry-last.
+type lastInvocation struct {
+	Args  []string `json:"args"`
+	Dir   string   `json:"dir,omitempty"`
+	Stdin *string  `json:"stdin,omitempty"`
+}
+
+// stdinSnapshot is what was read from STDIN, if anything, to be stored with
+// the last invocation.
+var stdinSnapshot atomic.Pointer[string]
+
+func lastInvocationPath(cachePath string) string {
+	return filepath.Join(cachePath, "last-invocation.json")
+}
+
+// writeLastInvocation stores the invocation, redacting the secrets in its
+// STDIN.
+func writeLastInvocation(path string, inv lastInvocation) error {
+	if inv.Stdin != nil {
+		stdin := redactSecrets(*inv.Stdin)
+		inv.Stdin = &stdin
+	}
+	bts, err := json.Marshal(inv)
+	if err != nil {
+		return fmt.Errorf("could not encode the invocation: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("could not create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, bts, 0o600); err != nil {
+		return fmt.Errorf("could not write %s: %w", path, err)
+	}
+	return nil
+}
+
+// readLastInvocation reads the stored invocation, if any.
+func readLastInvocation(path string) (*lastInvocation, error) {
+	bts, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	var inv lastInvocation
+	if err := json.Unmarshal(bts, &inv); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	return &inv, nil
+}
+
+// saveLastInvocation stores the current invocation for --retry-last.
+func saveLastInvocation() {
+	dir, _ := os.Getwd()
+	inv := lastInvocation{
+		Args:  os.Args[1:],
+		Dir:   dir,
+		Stdin: stdinSnapshot.Load(),
+	}
+	if err := writeLastInvocation(lastInvocationPath(config.CachePath), inv); err != nil {
+		// not being able to retry it later should not fail this one.
+		fmt.Fprintln(os.Stderr, "Could not save the command for --retry-last:", err)
+	}
+}
+
+// retryLast runs the last command that sent a prompt again, with the same
+// arguments, working directory, and STDIN.
+func retryLast() error {
+	inv, err := readLastInvocation(lastInvocationPath(config.CachePath))
+	if err != nil {
+		return modsError{err, "Could not read the last command."}
+	}
+	if inv == nil {
+		return modsError{
+			err: newUserErrorf(
+				"%s runs the last command that sent a prompt again, run one first.",
+				stderrStyles().InlineCode.Render("--retry-last"),
+			),
+			reason: "There is no command to retry.",
+		}
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return modsError{err, "Could not find the mods executable."}
+	}
+
+	if !config.Quiet {
+		fmt.Fprintln(
+			os.Stderr,
+			"Retrying:",
+			stderrStyles().InlineCode.Render(redactSecrets(strings.Join(append([]string{"mods"}, inv.Args...), " "))),
+		)
+	}
+	cmd := exec.Command(exe, inv.Args...) //nolint:gosec
+	cmd.Dir = inv.Dir
+	cmd.Stdin = os.Stdin
+	if inv.Stdin != nil {
+		cmd.Stdin = strings.NewReader(*inv.Stdin)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return modsError{err, "The retried command failed."}
+	}
+	return nil
+}
+
+// forgetLast removes the stored last command.
+func forgetLast() error {
+	if err := os.Remove(lastInvocationPath(config.CachePath)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return modsError{err, "Could not forget the last command."}
+	}
+	if !config.Quiet {
+		fmt.Fprintln(os.Stderr, "Forgot the last command.")
+	}
+	return nil
+}
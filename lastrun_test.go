@@ -0,0 +1,41 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastInvocation(t *testing.T) {
+	t.Run("missing", func(t *testing.T) {
+		inv, err := readLastInvocation(filepath.Join(t.TempDir(), "last-invocation.json"))
+		require.NoError(t, err)
+		require.Nil(t, inv)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		path := lastInvocationPath(t.TempDir())
+		stdin := "the key is " + knownSecrets["openai"]
+		require.NoError(t, writeLastInvocation(path, lastInvocation{
+			Args:  []string{"-m", "gpt-4o", "explain this"},
+			Dir:   "/src",
+			Stdin: &stdin,
+		}))
+
+		inv, err := readLastInvocation(path)
+		require.NoError(t, err)
+		require.Equal(t, []string{"-m", "gpt-4o", "explain this"}, inv.Args)
+		require.Equal(t, "/src", inv.Dir)
+		require.NotNil(t, inv.Stdin)
+		require.Equal(t, "the key is sk-p****6789", *inv.Stdin)
+	})
+
+	t.Run("no stdin", func(t *testing.T) {
+		path := lastInvocationPath(t.TempDir())
+		require.NoError(t, writeLastInvocation(path, lastInvocation{Args: []string{"hi"}}))
+		inv, err := readLastInvocation(path)
+		require.NoError(t, err)
+		require.Nil(t, inv.Stdin)
+	})
+}
@@ -0,0 +1,36 @@
+package main
+
+import "strings"
+
+// lineBuffer holds the streamed output until there's a complete line, so
+// line-oriented tools (e.g. grep, awk) downstream never see partial lines.
+type lineBuffer struct {
+	partial strings.Builder
+}
+
+// write adds the given content to the buffer, returning all the complete
+// lines it now has, if any.
+func (b *lineBuffer) write(s string) string {
+	b.partial.WriteString(s)
+	buffered := b.partial.String()
+	idx := strings.LastIndexByte(buffered, '\n')
+	if idx < 0 {
+		return ""
+	}
+	b.partial.Reset()
+	b.partial.WriteString(buffered[idx+1:])
+	return buffered[:idx+1]
+}
+
+// flush returns whatever is left in the buffer, complete line or not.
+func (b *lineBuffer) flush() string {
+	s := b.partial.String()
+	b.partial.Reset()
+	return s
+}
+
+// lineBuffered returns whether the raw output should be line buffered, which
+// is always the case when STDOUT is not a TTY.
+func (m *Mods) lineBuffered() bool {
+	return m.Config.LineBuffered || !isOutputTTY()
+}
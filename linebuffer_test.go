@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineBuffer(t *testing.T) {
+	chunks := []string{"hel", "lo\nwor", "ld", "\n", "foo\nbar\nba", "z"}
+
+	var b lineBuffer
+	var out strings.Builder
+	for _, chunk := range chunks {
+		s := b.write(chunk)
+		if s != "" {
+			require.True(t, strings.HasSuffix(s, "\n"), "partial line emitted mid-stream: %q", s)
+		}
+		out.WriteString(s)
+	}
+	require.Equal(t, "hello\nworld\nfoo\nbar\n", out.String())
+
+	out.WriteString(b.flush())
+	require.Equal(t, strings.Join(chunks, ""), out.String())
+	require.Empty(t, b.flush())
+}
+
+func TestLineBufferedRawTTY(t *testing.T) {
+	tty, stdout := isOutputTTY, os.Stdout
+	t.Cleanup(func() { isOutputTTY, os.Stdout = tty, stdout })
+	isOutputTTY = func() bool { return true }
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	require.NoError(t, err)
+	os.Stdout = f
+
+	m := &Mods{Config: &Config{Raw: true, LineBuffered: true}, contentMutex: &sync.Mutex{}}
+	m.appendToOutput("first line\nsec")
+	m.state = responseState
+	m.View()
+	m.appendToOutput("ond")
+	m.View()
+	_, _ = m.Update(completionOutput{})
+	require.Equal(t, doneState, m.state)
+	m.View()
+
+	require.NoError(t, f.Close())
+	bts, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	require.Equal(t, "first line\nsecond", string(bts))
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/exp/ordered"
+)
+
+const defaultLogLevel = "warn"
+
+// setupLogger sets the default [slog.Logger] according to the log-level,
+// log-output, and log-file settings.
+//
+// Without log-output and log-file, the logs only go to standard err if the
+// log level was given with --log-level or MODS_LOG_LEVEL, as they would
+// otherwise mix with the output, and are discarded. If syslog or journald are
+// not available, it logs to standard err instead.
+// The returned function should be called to close the log file or
+// connection, if any.
+func setupLogger(cfg *Config) (func() error, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(ordered.First(cfg.LogLevel, defaultLogLevel))); err != nil {
+		return nil, modsError{
+			err: newUserErrorf(
+				"Valid log levels are: %s",
+				strings.Join([]string{"debug", "info", "warn", "error"}, ", "),
+			),
+			reason: fmt.Sprintf("Invalid log level %q.", cfg.LogLevel),
+		}
+	}
+
+	output := cfg.LogOutput
+	discard := false
+	if output == "" {
+		output = logOutputStderr
+		if cfg.LogFile != "" {
+			output = logOutputFile
+		}
+		discard = output == logOutputStderr && !cfg.logLevelSet
+	}
+	if !slices.Contains(logOutputs, output) {
+		return nil, modsError{
+			err: newUserErrorf(
+				"Valid log outputs are: %s",
+				strings.Join(logOutputs, ", "),
+			),
+			reason: fmt.Sprintf("Invalid log output %q.", cfg.LogOutput),
+		}
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	}
+	var handler slog.Handler
+	var fallback error
+	closer := func() error { return nil }
+	switch output {
+	case logOutputFile:
+		if cfg.LogFile == "" {
+			return nil, modsError{
+				err:    newUserErrorf("Set the file to write the logs to with %s.", stderrStyles().InlineCode.Render("--log-file")),
+				reason: "Missing log file.",
+			}
+		}
+		f, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, modsError{err, "Could not open the log file."}
+		}
+		handler = slog.NewTextHandler(f, opts)
+		closer = f.Close
+	case logOutputSyslog, logOutputJournald:
+		newHandler := newSyslogHandler
+		if output == logOutputJournald {
+			newHandler = newJournaldHandler
+		}
+		h, c, err := newHandler(opts)
+		if err != nil {
+			fallback = err
+			break
+		}
+		handler, closer = logAttrsHandler{h}, c
+	}
+	switch {
+	case discard:
+		handler = slog.DiscardHandler
+	case handler == nil:
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	}
+
+	if cfg.BugReport {
+		bugReport = newBugReporter(cfg.BugReportPrompt)
+		handler = bugReport.handler(handler)
+	}
+	slog.SetDefault(slog.New(handler))
+	if fallback != nil {
+		slog.Warn("logging to standard err instead", "output", output, "err", fallback)
+	}
+	return closer, nil
+}
+
+// debugHTTPClient returns a copy of the given client that logs every request
+// at the debug level, if enabled.
+func debugHTTPClient(ctx context.Context, c *http.Client) *http.Client {
+	if !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return c
+	}
+	var client http.Client
+	if c != nil {
+		client = *c
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.Transport = debugTransport{base}
+	return &client
+}
+
+type debugTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		slog.Debug("http request failed",
+			"method", req.Method,
+			"url", redactURL(req.URL.String()),
+			"duration", time.Since(start),
+			"err", err,
+		)
+		return resp, err //nolint:wrapcheck
+	}
+	attrs := []any{
+		"method", req.Method,
+		"url", redactURL(req.URL.String()),
+		"status", resp.StatusCode,
+		"duration", time.Since(start),
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		attrs = append(attrs, "headers", redactHeaders(resp.Header))
+	}
+	slog.Debug("http request", attrs...)
+	return resp, nil
+}
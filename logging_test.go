@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupLogger(t *testing.T) {
+	defaultLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	t.Run("default", func(t *testing.T) {
+		closer, err := setupLogger(&Config{LogLevel: "debug"})
+		require.NoError(t, err)
+		require.NoError(t, closer())
+		require.False(t, slog.Default().Enabled(context.Background(), slog.LevelError), "should not log to stderr unless asked to")
+	})
+
+	t.Run("level given", func(t *testing.T) {
+		stderr := os.Stderr
+		t.Cleanup(func() { os.Stderr = stderr })
+		f, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+		require.NoError(t, err)
+		os.Stderr = f
+
+		closer, err := setupLogger(&Config{logLevelSet: true})
+		require.NoError(t, err)
+		require.NoError(t, closer())
+		require.False(t, slog.Default().Enabled(context.Background(), slog.LevelInfo))
+		slog.Warn("hello")
+		require.NoError(t, f.Close())
+		bts, err := os.ReadFile(f.Name())
+		require.NoError(t, err)
+		require.Contains(t, string(bts), "level=WARN msg=hello")
+	})
+
+	t.Run("stderr output", func(t *testing.T) {
+		closer, err := setupLogger(&Config{LogOutput: logOutputStderr})
+		require.NoError(t, err)
+		require.NoError(t, closer())
+		require.True(t, slog.Default().Enabled(context.Background(), slog.LevelWarn))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := setupLogger(&Config{LogLevel: "loud"})
+		require.Error(t, err)
+	})
+
+	t.Run("debug to file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "mods.log")
+		closer, err := setupLogger(&Config{LogLevel: "debug", LogFile: path})
+		require.NoError(t, err)
+		slog.Debug("hello", "foo", "bar")
+		require.NoError(t, closer())
+
+		bts, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Contains(t, string(bts), "level=DEBUG msg=hello foo=bar")
+
+		client := debugHTTPClient(context.Background(), &http.Client{})
+		require.IsType(t, debugTransport{}, client.Transport)
+	})
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	logOutputStderr   = "stderr"
+	logOutputFile     = "file"
+	logOutputSyslog   = "syslog"
+	logOutputJournald = "journald"
+)
+
+var logOutputs = []string{logOutputStderr, logOutputFile, logOutputSyslog, logOutputJournald}
+
+// logAttrs are added to every record sent to syslog or journald, once they
+// are known.
+var logAttrs atomic.Pointer[[]slog.Attr]
+
+// setLogAttrs sets the provider and conversation the records sent to syslog
+// or journald have from now on.
+func setLogAttrs(provider, conversation string) {
+	attrs := []slog.Attr{slog.String("provider", provider)}
+	if conversation != "" {
+		attrs = append(attrs, slog.String("conversation", conversation))
+	}
+	logAttrs.Store(&attrs)
+}
+
+// logAttrsHandler adds the [logAttrs] to the records.
+type logAttrsHandler struct {
+	slog.Handler
+}
+
+func (h logAttrsHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs := logAttrs.Load(); attrs != nil {
+		r = r.Clone()
+		r.AddAttrs(*attrs...)
+	}
+	return h.Handler.Handle(ctx, r) //nolint:wrapcheck
+}
+
+func (h logAttrsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return logAttrsHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h logAttrsHandler) WithGroup(name string) slog.Handler {
+	return logAttrsHandler{h.Handler.WithGroup(name)}
+}
+
+// lineHandler formats the records as text, without the time and level, and
+// gives each of them to write as a single line, e.g. for syslog.
+type lineHandler struct {
+	slog.Handler
+	mu    *sync.Mutex
+	buf   *bytes.Buffer
+	write func(level slog.Level, line string) error
+}
+
+func newLineHandler(opts *slog.HandlerOptions, write func(level slog.Level, line string) error) slog.Handler {
+	buf := &bytes.Buffer{}
+	o := *opts
+	o.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+			return slog.Attr{}
+		}
+		if opts.ReplaceAttr != nil {
+			return opts.ReplaceAttr(groups, a)
+		}
+		return a
+	}
+	return &lineHandler{
+		Handler: slog.NewTextHandler(buf, &o),
+		mu:      &sync.Mutex{},
+		buf:     buf,
+		write:   write,
+	}
+}
+
+func (h *lineHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.buf.Reset()
+	if err := h.Handler.Handle(ctx, r); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return h.write(r.Level, strings.TrimSuffix(h.buf.String(), "\n"))
+}
+
+func (h *lineHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.Handler = h.Handler.WithAttrs(attrs)
+	return &next
+}
+
+func (h *lineHandler) WithGroup(name string) slog.Handler {
+	next := *h
+	next.Handler = h.Handler.WithGroup(name)
+	return &next
+}
+
+// journaldHandler sends the records to journald, using its native protocol,
+// with each attribute as a field.
+//
+// Every write to w must be sent as a single datagram.
+type journaldHandler struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	opts   slog.HandlerOptions
+	groups []string
+	fields []journaldField
+}
+
+type journaldField struct {
+	name, value string
+}
+
+func newJournaldHandlerTo(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+	return &journaldHandler{w: w, mu: &sync.Mutex{}, opts: *opts}
+}
+
+func (h *journaldHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+func (h *journaldHandler) Handle(_ context.Context, r slog.Record) error {
+	msg := slog.String(slog.MessageKey, r.Message)
+	if h.opts.ReplaceAttr != nil {
+		msg = h.opts.ReplaceAttr(nil, msg)
+	}
+	fields := []journaldField{
+		{"MESSAGE", msg.Value.String()},
+		{"PRIORITY", journaldPriority(r.Level)},
+		{"SYSLOG_IDENTIFIER", "mods"},
+	}
+	fields = append(fields, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = h.appendAttr(fields, h.groups, a)
+		return true
+	})
+
+	var buf bytes.Buffer
+	for _, f := range fields {
+		if !strings.Contains(f.value, "\n") {
+			buf.WriteString(f.name + "=" + f.value + "\n")
+			continue
+		}
+		// values with newlines are sent as the name, a newline, the size as
+		// a little endian uint64, and the value.
+		buf.WriteString(f.name + "\n")
+		_ = binary.Write(&buf, binary.LittleEndian, uint64(len(f.value)))
+		buf.WriteString(f.value + "\n")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err //nolint:wrapcheck
+}
+
+func (h *journaldHandler) appendAttr(fields []journaldField, groups []string, a slog.Attr) []journaldField {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			groups = append(slices.Clone(groups), a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			fields = h.appendAttr(fields, groups, ga)
+		}
+		return fields
+	}
+	if h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Key == "" {
+		return fields
+	}
+	return append(fields, journaldField{
+		journaldFieldName(append(slices.Clone(groups), a.Key)),
+		a.Value.String(),
+	})
+}
+
+func (h *journaldHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.fields = slices.Clone(h.fields)
+	for _, a := range attrs {
+		next.fields = h.appendAttr(next.fields, h.groups, a)
+	}
+	return &next
+}
+
+func (h *journaldHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := *h
+	next.groups = append(slices.Clone(h.groups), name)
+	return &next
+}
+
+// journaldFieldName returns the field name for the given attribute key,
+// which may only have uppercase letters, digits, and underscores, and
+// can't start with an underscore or a digit.
+func journaldFieldName(keys []string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.Join(keys, "_"))
+	switch {
+	case name == "MESSAGE", name == "PRIORITY", name == "SYSLOG_IDENTIFIER",
+		name[0] == '_', name[0] >= '0' && name[0] <= '9':
+		// don't clash with the fields we set, nor the trusted ones.
+		return "MODS_" + name
+	}
+	return name
+}
+
+// journaldPriority returns the syslog priority of the given level.
+func journaldPriority(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return "3"
+	case level >= slog.LevelWarn:
+		return "4"
+	case level >= slog.LevelInfo:
+		return "6"
+	default:
+		return "7"
+	}
+}
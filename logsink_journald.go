@@ -0,0 +1,21 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+)
+
+const journaldSocket = "/run/systemd/journal/socket"
+
+// newJournaldHandler returns a handler that sends the logs to journald, and
+// a function to close the connection.
+func newJournaldHandler(opts *slog.HandlerOptions) (slog.Handler, func() error, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journaldSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not connect to journald: %w", err)
+	}
+	return newJournaldHandlerTo(conn, opts), conn.Close, nil
+}
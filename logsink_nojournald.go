@@ -0,0 +1,13 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+)
+
+func newJournaldHandler(*slog.HandlerOptions) (slog.Handler, func() error, error) {
+	return nil, nil, fmt.Errorf("journald is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+)
+
+func newSyslogHandler(*slog.HandlerOptions) (slog.Handler, func() error, error) {
+	return nil, nil, fmt.Errorf("syslog is not supported on %s", runtime.GOOS)
+}
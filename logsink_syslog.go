@@ -0,0 +1,30 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"log/syslog"
+)
+
+// newSyslogHandler returns a handler that sends the logs to the local
+// syslog, and a function to close the connection.
+func newSyslogHandler(opts *slog.HandlerOptions) (slog.Handler, func() error, error) {
+	w, err := syslog.New(syslog.LOG_USER|syslog.LOG_INFO, "mods")
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not connect to syslog: %w", err)
+	}
+	return newLineHandler(opts, func(level slog.Level, line string) error {
+		switch {
+		case level >= slog.LevelError:
+			return w.Err(line) //nolint:wrapcheck
+		case level >= slog.LevelWarn:
+			return w.Warning(line) //nolint:wrapcheck
+		case level >= slog.LevelInfo:
+			return w.Info(line) //nolint:wrapcheck
+		default:
+			return w.Debug(line) //nolint:wrapcheck
+		}
+	}), w.Close, nil
+}
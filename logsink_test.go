@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// datagrams records every write as a datagram.
+type datagrams [][]byte
+
+func (d *datagrams) Write(p []byte) (int, error) {
+	*d = append(*d, bytes.Clone(p))
+	return len(p), nil
+}
+
+// parseJournald parses a datagram of the journald native protocol.
+func parseJournald(t *testing.T, p []byte) map[string]string {
+	t.Helper()
+	fields := map[string]string{}
+	for len(p) > 0 {
+		end := bytes.IndexByte(p, '\n')
+		require.Positive(t, end)
+		line := string(p[:end])
+		p = p[end+1:]
+		if name, value, ok := strings.Cut(line, "="); ok {
+			fields[name] = value
+			continue
+		}
+		size := binary.LittleEndian.Uint64(p[:8])
+		fields[line] = string(p[8 : 8+size])
+		p = p[8+size+1:]
+	}
+	return fields
+}
+
+func TestJournaldHandler(t *testing.T) {
+	var out datagrams
+	logger := slog.New(newJournaldHandlerTo(&out, &slog.HandlerOptions{
+		Level:       slog.LevelInfo,
+		ReplaceAttr: redactAttr,
+	}))
+
+	logger.Debug("not sent")
+	logger.With("attempt", 2).WithGroup("http").Warn(
+		"request failed",
+		"status", 500,
+		"err", "bad key "+knownSecrets["openai"],
+		"body", "line 1\nline 2",
+		"priority", "high",
+	)
+	require.Len(t, out, 1)
+
+	fields := parseJournald(t, out[0])
+	require.Equal(t, "request failed", fields["MESSAGE"])
+	require.Equal(t, "4", fields["PRIORITY"])
+	require.Equal(t, "mods", fields["SYSLOG_IDENTIFIER"])
+	require.Equal(t, "2", fields["ATTEMPT"])
+	require.Equal(t, "500", fields["HTTP_STATUS"])
+	require.Equal(t, "line 1\nline 2", fields["HTTP_BODY"])
+	require.Equal(t, "high", fields["HTTP_PRIORITY"])
+	require.NotContains(t, fields["HTTP_ERR"], knownSecrets["openai"])
+}
+
+func TestJournaldFieldName(t *testing.T) {
+	for expect, keys := range map[string][]string{
+		"CONVERSATION":     {"conversation"},
+		"HTTP_STATUS_CODE": {"http", "status-code"},
+		"MODS_MESSAGE":     {"message"},
+		"MODS_PRIORITY":    {"priority"},
+		"MODS__CURSOR":     {"_cursor"},
+		"MODS_2FA":         {"2fa"},
+		"TOOL_NAME__AND_":  {"tool", "name", "ñandú"},
+	} {
+		t.Run(expect, func(t *testing.T) {
+			require.Equal(t, expect, journaldFieldName(keys))
+		})
+	}
+}
+
+func TestLineHandler(t *testing.T) {
+	type line struct {
+		level slog.Level
+		text  string
+	}
+	var lines []line
+	logger := slog.New(newLineHandler(&slog.HandlerOptions{Level: slog.LevelDebug}, func(level slog.Level, text string) error {
+		lines = append(lines, line{level, text})
+		return nil
+	}))
+	logger.With("api", "openai").Error("request failed", "status", 500)
+	logger.Debug("hello")
+	require.Equal(t, []line{
+		{slog.LevelError, `msg="request failed" api=openai status=500`},
+		{slog.LevelDebug, "msg=hello"},
+	}, lines)
+}
+
+func TestLogAttrs(t *testing.T) {
+	t.Cleanup(func() { logAttrs.Store(nil) })
+
+	var out datagrams
+	logger := slog.New(logAttrsHandler{newJournaldHandlerTo(&out, &slog.HandlerOptions{})})
+	logger.Info("before")
+	setLogAttrs("anthropic", "df31ae23ab8b75b5643c2f846c570997edc71333")
+	logger.Info("after")
+
+	require.Len(t, out, 2)
+	require.NotContains(t, parseJournald(t, out[0]), "PROVIDER")
+	fields := parseJournald(t, out[1])
+	require.Equal(t, "anthropic", fields["PROVIDER"])
+	require.Equal(t, "df31ae23ab8b75b5643c2f846c570997edc71333", fields["CONVERSATION"])
+}
+
+func TestSetupLoggerOutput(t *testing.T) {
+	defaultLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := setupLogger(&Config{LogOutput: "cloud"})
+		require.Error(t, err)
+		require.Equal(t, `Invalid log output "cloud".`, err.(modsError).reason)
+	})
+
+	t.Run("file without log file", func(t *testing.T) {
+		_, err := setupLogger(&Config{LogOutput: logOutputFile})
+		require.Error(t, err)
+	})
+
+	t.Run("falls back to stderr", func(t *testing.T) {
+		if _, closer, err := newJournaldHandler(&slog.HandlerOptions{}); err == nil {
+			require.NoError(t, closer())
+			t.Skip("journald is available")
+		}
+		stderr := os.Stderr
+		t.Cleanup(func() { os.Stderr = stderr })
+		f, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+		require.NoError(t, err)
+		os.Stderr = f
+
+		closer, err := setupLogger(&Config{LogOutput: logOutputJournald})
+		require.NoError(t, err)
+		require.NoError(t, closer())
+		require.True(t, slog.Default().Enabled(context.Background(), slog.LevelWarn))
+		require.NoError(t, f.Close())
+		bts, err := os.ReadFile(f.Name())
+		require.NoError(t, err)
+		require.Contains(t, string(bts), "logging to standard err instead")
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime/debug"
 	"runtime/pprof"
 	"slices"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/atotto/clipboard"
 	timeago "github.com/caarlos0/timea.go"
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	glamour "github.com/charmbracelet/glamour/styles"
 	"github.com/charmbracelet/huh"
@@ -77,9 +79,133 @@ var (
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		Example:       randomExample(),
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) (rerr error) {
+			// these only print or edit the settings, so they must work even
+			// if the settings are invalid.
+			if config.Dirs {
+				if len(args) > 0 {
+					switch args[0] {
+					case "config":
+						fmt.Println(filepath.Dir(config.SettingsPath))
+						return nil
+					case "cache":
+						fmt.Println(config.CachePath)
+						return nil
+					}
+				}
+				fmt.Printf("Configuration: %s\n", filepath.Dir(config.SettingsPath))
+				//nolint:mnd
+				fmt.Printf("%*sCache: %s\n", 8, " ", config.CachePath)
+				return nil
+			}
+
+			if config.Settings {
+				c, err := editor.Cmd("mods", config.SettingsPath)
+				if err != nil {
+					return modsError{
+						err:    err,
+						reason: "Could not edit your settings file.",
+					}
+				}
+				c.Stdin = os.Stdin
+				c.Stdout = os.Stdout
+				c.Stderr = os.Stderr
+				if err := c.Run(); err != nil {
+					return modsError{err, fmt.Sprintf(
+						"Missing %s.",
+						stderrStyles().InlineCode.Render("$EDITOR"),
+					)}
+				}
+
+				if !config.Quiet {
+					fmt.Fprintln(os.Stderr, "Wrote config file to:", config.SettingsPath)
+				}
+				return nil
+			}
+
+			if config.ResetSettings {
+				return resetSettings()
+			}
+
+			config.logLevelSet = cmd.Flags().Changed("log-level") || os.Getenv("MODS_LOG_LEVEL") != ""
+			closeLog, err := setupLogger(&config)
+			if err != nil {
+				return err
+			}
+			defer closeLog() //nolint:errcheck
+			if bugReport != nil {
+				defer func() { writeBugReport(rerr) }()
+			}
+
 			config.Prefix = removeWhitespace(strings.Join(args, " "))
 
+			if config.InputFormat != "" && !slices.Contains(inputFormats, config.InputFormat) {
+				return modsError{
+					err: newUserErrorf(
+						"Valid input formats are: %s",
+						strings.Join(inputFormats, ", "),
+					),
+					reason: fmt.Sprintf("Invalid input format %q.", config.InputFormat),
+				}
+			}
+
+			if config.Favorites && !config.List {
+				return modsError{
+					err: newUserErrorf(
+						"Try %s.",
+						stderrStyles().InlineCode.Render("mods --list --favorites"),
+					),
+					reason: fmt.Sprintf(
+						"%s only works with %s.",
+						stderrStyles().InlineCode.Render("--favorites"),
+						stderrStyles().InlineCode.Render("--list"),
+					),
+				}
+			}
+
+			if err := validateSettings(); err != nil {
+				return err
+			}
+
+			if err := applyPreset(&config, cmd.Flags().Changed); err != nil {
+				return err
+			}
+
+			if err := applySummarize(&config, cmd.Flags().Changed); err != nil {
+				return err
+			}
+
+			if config.Reverify != "" {
+				return reverifyConversation(cmd.Context(), config.Reverify)
+			}
+
+			if config.RetryLast {
+				return retryLast()
+			}
+
+			if config.ForgetLast {
+				return forgetLast()
+			}
+
+			if config.Browse {
+				action, convo, err := browseConversations()
+				if err != nil {
+					return err
+				}
+				switch action {
+				case browseShow:
+					config.Show = convo.ID
+				case browseContinue:
+					config.Continue = convo.ID
+					if convo.API != nil && convo.Model != nil {
+						config.API, config.Model = *convo.API, *convo.Model
+					}
+				default:
+					return nil
+				}
+				config.Browse = false
+			}
+
 			opts := []tea.ProgramOption{}
 
 			if !isInputTTY() || config.Raw {
@@ -128,55 +254,14 @@ var (
 			}
 
 			mods = m.(*Mods)
+			printAttachReport(mods)
+			if mods.sent {
+				saveLastInvocation()
+			}
 			if mods.Error != nil {
 				return *mods.Error
 			}
 
-			if config.Dirs {
-				if len(args) > 0 {
-					switch args[0] {
-					case "config":
-						fmt.Println(filepath.Dir(config.SettingsPath))
-						return nil
-					case "cache":
-						fmt.Println(config.CachePath)
-						return nil
-					}
-				}
-				fmt.Printf("Configuration: %s\n", filepath.Dir(config.SettingsPath))
-				//nolint:mnd
-				fmt.Printf("%*sCache: %s\n", 8, " ", config.CachePath)
-				return nil
-			}
-
-			if config.Settings {
-				c, err := editor.Cmd("mods", config.SettingsPath)
-				if err != nil {
-					return modsError{
-						err:    err,
-						reason: "Could not edit your settings file.",
-					}
-				}
-				c.Stdin = os.Stdin
-				c.Stdout = os.Stdout
-				c.Stderr = os.Stderr
-				if err := c.Run(); err != nil {
-					return modsError{err, fmt.Sprintf(
-						"Missing %s.",
-						stderrStyles().InlineCode.Render("$EDITOR"),
-					)}
-				}
-
-				if !config.Quiet {
-					fmt.Fprintln(os.Stderr, "Wrote config file to:", config.SettingsPath)
-				}
-				return nil
-			}
-
-			if config.ResetSettings {
-				return resetSettings()
-			}
-
 			if mods.Input == "" && isNoArgs() {
 				return modsError{
 					reason: "You haven't provided any prompt input.",
@@ -214,29 +299,59 @@ var (
 				return deleteConversations()
 			}
 
-			if config.DeleteOlderThan > 0 {
-				return deleteConversationOlderThan()
+			if config.Favorite != "" {
+				return setFavorite(config.Favorite, true)
 			}
 
-			// raw mode already prints the output, no need to print it again
-			if isOutputTTY() && !config.Raw {
-				switch {
-				case mods.glamOutput != "":
-					fmt.Print(mods.glamOutput)
-				case mods.Output != "":
-					fmt.Print(mods.Output)
-				}
+			if config.Unfavorite != "" {
+				return setFavorite(config.Unfavorite, false)
 			}
 
-			if config.Show != "" || config.ShowLast {
-				return nil
+			if config.DeleteOlderThan > 0 {
+				return deleteConversationOlderThan()
 			}
 
-			if config.cacheWriteToID != "" {
-				return saveConversation(mods)
+			if config.PrintPrompt {
+				fmt.Println(mods.prompt)
+				return nil
 			}
 
-			return nil
+			for {
+				if err := printOutput(mods); err != nil {
+					return err
+				}
+
+				if config.Show != "" || config.ShowLast {
+					return nil
+				}
+
+				if config.ContinueFile != "" {
+					if err := saveConversationFile(mods); err != nil {
+						return err
+					}
+				} else if config.cacheWriteToID != "" {
+					if err := saveConversation(mods); err != nil {
+						return err
+					}
+				}
+
+				prompt, ok, err := nextPrompt(mods)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return nil
+				}
+
+				m, err := tea.NewProgram(mods.continueWith(prompt), opts...).Run()
+				if err != nil {
+					return modsError{err, "Couldn't start Bubble Tea program."}
+				}
+				mods = m.(*Mods)
+				if mods.Error != nil {
+					return *mods.Error
+				}
+			}
 		},
 	}
 )
@@ -249,16 +364,38 @@ func initFlags() {
 	flags.BoolVarP(&config.AskModel, "ask-model", "M", config.AskModel, stdoutStyles().FlagDesc.Render(help["ask-model"]))
 	flags.StringVarP(&config.API, "api", "a", config.API, stdoutStyles().FlagDesc.Render(help["api"]))
 	flags.StringVarP(&config.HTTPProxy, "http-proxy", "x", config.HTTPProxy, stdoutStyles().FlagDesc.Render(help["http-proxy"]))
+	flags.StringVar(&config.IPVersion, "ip-version", config.IPVersion, stdoutStyles().FlagDesc.Render(help["ip-version"]))
 	flags.BoolVarP(&config.Format, "format", "f", config.Format, stdoutStyles().FlagDesc.Render(help["format"]))
 	flags.StringVar(&config.FormatAs, "format-as", config.FormatAs, stdoutStyles().FlagDesc.Render(help["format-as"]))
 	flags.BoolVarP(&config.Raw, "raw", "r", config.Raw, stdoutStyles().FlagDesc.Render(help["raw"]))
+	flags.StringVar(&config.InputFormat, "input-format", inputFormatText, stdoutStyles().FlagDesc.Render(help["input-format"]))
+	flags.StringVar(&config.Fence, "fence", config.Fence, stdoutStyles().FlagDesc.Render(help["fence"]))
+	flags.Lookup("fence").NoOptDefVal = fenceAlways
+	flags.StringVar(&config.FenceLang, "fence-lang", config.FenceLang, stdoutStyles().FlagDesc.Render(help["fence-lang"]))
+	flags.BoolVar(&config.NoAutolang, "no-autolang", config.NoAutolang, stdoutStyles().FlagDesc.Render(help["no-autolang"]))
+	flags.BoolVar(&config.LineBuffered, "line-buffered", config.LineBuffered, stdoutStyles().FlagDesc.Render(help["line-buffered"]))
+	flags.BoolVar(&config.NoContextFiles, "no-context-files", config.NoContextFiles, stdoutStyles().FlagDesc.Render(help["no-context-files"]))
+	flags.StringArrayVar(&config.AttachDirs, "attach-dir", nil, stdoutStyles().FlagDesc.Render(help["attach-dir"]))
+	flags.StringArrayVar(&config.AttachInclude, "include", nil, stdoutStyles().FlagDesc.Render(help["include"]))
+	flags.StringArrayVar(&config.AttachExclude, "exclude", nil, stdoutStyles().FlagDesc.Render(help["exclude"]))
+	flags.BoolVar(&config.Timestamp, "timestamp", config.Timestamp, stdoutStyles().FlagDesc.Render(help["timestamp"]))
+	flags.StringVar(&config.TimestampFormat, "timestamp-format", config.TimestampFormat, stdoutStyles().FlagDesc.Render(help["timestamp-format"]))
 	flags.IntVarP(&config.IncludePrompt, "prompt", "P", config.IncludePrompt, stdoutStyles().FlagDesc.Render(help["prompt"]))
 	flags.BoolVarP(&config.IncludePromptArgs, "prompt-args", "p", config.IncludePromptArgs, stdoutStyles().FlagDesc.Render(help["prompt-args"]))
+	flags.BoolVar(&config.PrintPrompt, "print-prompt", config.PrintPrompt, stdoutStyles().FlagDesc.Render(help["print-prompt"]))
 	flags.StringVarP(&config.Continue, "continue", "c", "", stdoutStyles().FlagDesc.Render(help["continue"]))
 	flags.BoolVarP(&config.ContinueLast, "continue-last", "C", false, stdoutStyles().FlagDesc.Render(help["continue-last"]))
+	flags.StringVar(&config.ContinueFile, "continue-file", "", stdoutStyles().FlagDesc.Render(help["continue-file"]))
 	flags.BoolVarP(&config.List, "list", "l", config.List, stdoutStyles().FlagDesc.Render(help["list"]))
+	flags.BoolVar(&config.Browse, "browse", config.Browse, stdoutStyles().FlagDesc.Render(help["browse"]))
+	flags.StringVar(&config.Reverify, "reverify", config.Reverify, stdoutStyles().FlagDesc.Render(help["reverify"]))
+	flags.BoolVar(&config.RetryLast, "retry-last", false, stdoutStyles().FlagDesc.Render(help["retry-last"]))
+	flags.BoolVar(&config.ForgetLast, "forget-last", false, stdoutStyles().FlagDesc.Render(help["forget-last"]))
 	flags.StringVarP(&config.Title, "title", "t", config.Title, stdoutStyles().FlagDesc.Render(help["title"]))
 	flags.StringArrayVarP(&config.Delete, "delete", "d", config.Delete, stdoutStyles().FlagDesc.Render(help["delete"]))
+	flags.StringVar(&config.Favorite, "favorite", config.Favorite, stdoutStyles().FlagDesc.Render(help["favorite"]))
+	flags.StringVar(&config.Unfavorite, "unfavorite", config.Unfavorite, stdoutStyles().FlagDesc.Render(help["unfavorite"]))
+	flags.BoolVar(&config.Favorites, "favorites", config.Favorites, stdoutStyles().FlagDesc.Render(help["favorites"]))
 	flags.Var(newDurationFlag(config.DeleteOlderThan, &config.DeleteOlderThan), "delete-older-than", stdoutStyles().FlagDesc.Render(help["delete-older-than"]))
 	flags.StringVarP(&config.Show, "show", "s", config.Show, stdoutStyles().FlagDesc.Render(help["show"]))
 	flags.BoolVarP(&config.ShowLast, "show-last", "S", false, stdoutStyles().FlagDesc.Render(help["show-last"]))
@@ -269,30 +406,67 @@ func initFlags() {
 	flags.BoolVar(&config.NoLimit, "no-limit", config.NoLimit, stdoutStyles().FlagDesc.Render(help["no-limit"]))
 	flags.Int64Var(&config.MaxTokens, "max-tokens", config.MaxTokens, stdoutStyles().FlagDesc.Render(help["max-tokens"]))
 	flags.IntVar(&config.WordWrap, "word-wrap", config.WordWrap, stdoutStyles().FlagDesc.Render(help["word-wrap"]))
+	flags.StringVar(&config.Pager, "pager", config.Pager, stdoutStyles().FlagDesc.Render(help["pager"]))
+	flags.BoolVar(&config.NoPager, "no-pager", config.NoPager, stdoutStyles().FlagDesc.Render(help["no-pager"]))
+	flags.BoolVar(&config.NoTrim, "no-trim", config.NoTrim, stdoutStyles().FlagDesc.Render(help["no-trim"]))
+	flags.StringVar(&config.EnvFile, "env-file", config.EnvFile, stdoutStyles().FlagDesc.Render(help["env-file"]))
+	flags.BoolVar(&config.BugReport, "bug-report", config.BugReport, stdoutStyles().FlagDesc.Render(help["bug-report"]))
+	flags.BoolVar(&config.BugReportPrompt, "include-prompt", config.BugReportPrompt, stdoutStyles().FlagDesc.Render(help["include-prompt"]))
 	flags.Float64Var(&config.Temperature, "temp", config.Temperature, stdoutStyles().FlagDesc.Render(help["temp"]))
 	flags.StringArrayVar(&config.Stop, "stop", config.Stop, stdoutStyles().FlagDesc.Render(help["stop"]))
 	flags.Float64Var(&config.TopP, "topp", config.TopP, stdoutStyles().FlagDesc.Render(help["topp"]))
 	flags.Int64Var(&config.TopK, "topk", config.TopK, stdoutStyles().FlagDesc.Render(help["topk"]))
+	flags.StringVar(&config.Preset, "preset", config.Preset, stdoutStyles().FlagDesc.Render(help["preset"]))
+	flags.Var(newPenaltyFlag(&config.FrequencyPenalty), "frequency-penalty", stdoutStyles().FlagDesc.Render(help["frequency-penalty"]))
+	flags.Var(newPenaltyFlag(&config.PresencePenalty), "presence-penalty", stdoutStyles().FlagDesc.Render(help["presence-penalty"]))
 	flags.UintVar(&config.Fanciness, "fanciness", config.Fanciness, stdoutStyles().FlagDesc.Render(help["fanciness"]))
+	flags.StringVar(&config.PostProcessCommand, "post-process-command", config.PostProcessCommand, stdoutStyles().FlagDesc.Render(help["post-process-command"]))
+	flags.StringVar(&config.AssistantLabel, "assistant-label", config.AssistantLabel, stdoutStyles().FlagDesc.Render(help["assistant-label"]))
 	flags.StringVar(&config.StatusText, "status-text", config.StatusText, stdoutStyles().FlagDesc.Render(help["status-text"]))
+	flags.StringVar(&config.LogLevel, "log-level", config.LogLevel, stdoutStyles().FlagDesc.Render(help["log-level"]))
+	flags.StringVar(&config.LogFile, "log-file", config.LogFile, stdoutStyles().FlagDesc.Render(help["log-file"]))
+	flags.StringVar(&config.LogOutput, "log-output", config.LogOutput, stdoutStyles().FlagDesc.Render(help["log-output"]))
+	flags.BoolVar(&config.Verbose, "verbose", config.Verbose, stdoutStyles().FlagDesc.Render(help["verbose"]))
+	flags.Var(newDurationFlag(config.MaxTimePerToken, &config.MaxTimePerToken), "max-time-per-token", stdoutStyles().FlagDesc.Render(help["max-time-per-token"]))
+	flags.Var(newDurationFlag(config.StdinTimeout, &config.StdinTimeout), "stdin-timeout", stdoutStyles().FlagDesc.Render(help["stdin-timeout"]))
 	flags.BoolVar(&config.NoCache, "no-cache", config.NoCache, stdoutStyles().FlagDesc.Render(help["no-cache"]))
+	flags.IntVar(&config.MaxConversationsPerDay, "max-conversations-per-day", config.MaxConversationsPerDay, stdoutStyles().FlagDesc.Render(help["max-conversations-per-day"]))
+	flags.StringVar(&config.MaxConversationsAction, "max-conversations-action", config.MaxConversationsAction, stdoutStyles().FlagDesc.Render(help["max-conversations-action"]))
 	flags.BoolVar(&config.ResetSettings, "reset-settings", config.ResetSettings, stdoutStyles().FlagDesc.Render(help["reset-settings"]))
 	flags.BoolVar(&config.Settings, "settings", false, stdoutStyles().FlagDesc.Render(help["settings"]))
 	flags.BoolVar(&config.Dirs, "dirs", false, stdoutStyles().FlagDesc.Render(help["dirs"]))
 	flags.StringVarP(&config.Role, "role", "R", config.Role, stdoutStyles().FlagDesc.Render(help["role"]))
+	flags.StringVar(&config.RoleURL, "role-url", config.RoleURL, stdoutStyles().FlagDesc.Render(help["role-url"]))
+	flags.StringVar(&config.PromptURL, "prompt-url", config.PromptURL, stdoutStyles().FlagDesc.Render(help["prompt-url"]))
+	flags.BoolVar(&config.AllowRemote, "allow-remote", config.AllowRemote, stdoutStyles().FlagDesc.Render(help["allow-remote"]))
+	flags.StringVar(&config.OutputLanguage, "lang", config.OutputLanguage, stdoutStyles().FlagDesc.Render(help["output-language"]))
+	flags.BoolVar(&config.Summarize, "summarize", false, stdoutStyles().FlagDesc.Render(help["summarize"]))
+	flags.StringVar(&config.SummaryLength, "length", summaryMedium, stdoutStyles().FlagDesc.Render(help["length"]))
+	flags.BoolVar(&config.NoRole, "no-role", config.NoRole, stdoutStyles().FlagDesc.Render(help["no-role"]))
+	flags.IntVar(&config.ShellHistory, "shell-history", config.ShellHistory, stdoutStyles().FlagDesc.Render(help["shell-history"]))
+	flags.BoolVar(&config.AutoClarify, "auto-clarify", config.AutoClarify, stdoutStyles().FlagDesc.Render(help["auto-clarify"]))
+	flags.BoolVar(&config.Choose, "choose", config.Choose, stdoutStyles().FlagDesc.Render(help["choose"]))
+	flags.BoolVar(&config.Agent, "agent", config.Agent, stdoutStyles().FlagDesc.Render(help["agent"]))
+	flags.IntVar(&config.AgentMaxSteps, "max-steps", config.AgentMaxSteps, stdoutStyles().FlagDesc.Render(help["max-steps"]))
+	flags.StringVar(&config.AgentStop, "agent-stop", config.AgentStop, stdoutStyles().FlagDesc.Render(help["agent-stop"]))
 	flags.BoolVar(&config.ListRoles, "list-roles", config.ListRoles, stdoutStyles().FlagDesc.Render(help["list-roles"]))
 	flags.StringVar(&config.Theme, "theme", "charm", stdoutStyles().FlagDesc.Render(help["theme"]))
 	flags.BoolVarP(&config.openEditor, "editor", "e", false, stdoutStyles().FlagDesc.Render(help["editor"]))
 	flags.BoolVar(&config.MCPList, "mcp-list", false, stdoutStyles().FlagDesc.Render(help["mcp-list"]))
 	flags.BoolVar(&config.MCPListTools, "mcp-list-tools", false, stdoutStyles().FlagDesc.Render(help["mcp-list-tools"]))
 	flags.StringArrayVar(&config.MCPDisable, "mcp-disable", nil, stdoutStyles().FlagDesc.Render(help["mcp-disable"]))
+	flags.BoolVar(&config.StrictTools, "strict-tools", config.StrictTools, stdoutStyles().FlagDesc.Render(help["strict-tools"]))
+	flags.IntVar(&config.StrictToolsRetries, "strict-tools-retries", config.StrictToolsRetries, stdoutStyles().FlagDesc.Render(help["strict-tools-retries"]))
+	flags.Var(newOptionalBoolFlag(&config.ParallelToolCalls), "parallel-tool-calls", stdoutStyles().FlagDesc.Render(help["parallel-tool-calls"]))
 	flags.Lookup("prompt").NoOptDefVal = "-1"
+	flags.Lookup("pager").NoOptDefVal = pagerAuto
+	flags.Lookup("parallel-tool-calls").NoOptDefVal = "true"
 	flags.SortFlags = false
 
 	flags.BoolVar(&memprofile, "memprofile", false, "Write memory profiles to CWD")
 	_ = flags.MarkHidden("memprofile")
 
-	for _, name := range []string{"show", "delete", "continue"} {
+	for _, name := range []string{"show", "delete", "continue", "favorite", "unfavorite"} {
 		_ = rootCmd.RegisterFlagCompletionFunc(name, func(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			results, _ := db.Completions(toComplete)
 			return results, cobra.ShellCompDirectiveDefault
@@ -324,13 +498,26 @@ func initFlags() {
 		"show-last",
 		"delete",
 		"delete-older-than",
+		"favorite",
+		"unfavorite",
 		"list",
+		"browse",
+		"reverify",
+		"retry-last",
+		"forget-last",
 		"continue",
 		"continue-last",
 		"reset-settings",
 		"mcp-list",
 		"mcp-list-tools",
 	)
+	rootCmd.MarkFlagsMutuallyExclusive("pager", "no-pager")
+	rootCmd.MarkFlagsMutuallyExclusive("print-prompt", "show", "show-last")
+	// --continue and --title can go together, so this can't be a single group.
+	for _, name := range []string{"continue", "continue-last", "title", "show", "show-last"} {
+		rootCmd.MarkFlagsMutuallyExclusive("continue-file", name)
+	}
+	rootCmd.MarkFlagsMutuallyExclusive("role", "no-role", "role-url", "summarize")
 }
 
 func main() {
@@ -370,6 +557,14 @@ func main() {
 		rootCmd.InitDefaultCompletionCmd()
 	}
 
+	if isAuthCmd(os.Args) {
+		rootCmd.AddCommand(newAuthCmd())
+	}
+
+	if isEvalCmd(os.Args) {
+		rootCmd.AddCommand(newEvalCmd())
+	}
+
 	if isManCmd(os.Args) {
 		rootCmd.AddCommand(&cobra.Command{
 			Use:                   "man",
@@ -440,7 +635,7 @@ func maybeWriteMemProfile() {
 func handleError(err error) {
 	maybeWriteMemProfile()
 	// exhaust stdin
-	if !isInputTTY() {
+	if !isInputTTY() && !stdinAbandoned.Load() {
 		_, _ = io.ReadAll(os.Stdin)
 	}
 
@@ -464,23 +659,34 @@ func handleError(err error) {
 		}
 	} else if errors.As(err, &merr) {
 		args = []any{
-			stderrStyles().ErrPadding.Render(stderrStyles().ErrorHeader.String(), merr.reason),
+			stderrStyles().ErrPadding.Render(stderrStyles().ErrorHeader.String(), redactSecrets(merr.reason)),
 		}
 
 		// Skip the error details if the user simply canceled out of huh.
 		if merr.err != huh.ErrUserAborted {
 			format += "%s\n\n"
-			args = append(args, stderrStyles().ErrPadding.Render(stderrStyles().ErrorDetails.Render(err.Error())))
+			args = append(args, stderrStyles().ErrPadding.Render(stderrStyles().ErrorDetails.Render(redactSecrets(err.Error()))))
 		}
 	} else {
 		args = []any{
-			stderrStyles().ErrPadding.Render(stderrStyles().ErrorDetails.Render(err.Error())),
+			stderrStyles().ErrPadding.Render(stderrStyles().ErrorDetails.Render(redactSecrets(err.Error()))),
 		}
 	}
 
 	fmt.Fprintf(os.Stderr, format, args...)
 }
 
+// writeBugReport writes the report of the run, and prints where it was
+// written.
+func writeBugReport(err error) {
+	path, werr := bugReport.write(&config, err)
+	if werr != nil {
+		fmt.Fprintln(os.Stderr, "Could not write the bug report:", werr)
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Wrote bug report to:", path)
+}
+
 func resetSettings() error {
 	_, err := os.Stat(config.SettingsPath)
 	if err != nil {
@@ -592,6 +798,24 @@ func deleteConversations() error {
 	return nil
 }
 
+func setFavorite(in string, favorite bool) error {
+	convo, err := db.Find(in)
+	if err != nil {
+		return modsError{err, "Couldn't find conversation."}
+	}
+	if err := db.SetFavorite(convo.ID, favorite); err != nil {
+		return modsError{err, "Couldn't update conversation."}
+	}
+	if !config.Quiet {
+		action := "Conversation added to favorites:"
+		if !favorite {
+			action = "Conversation removed from favorites:"
+		}
+		fmt.Fprintln(os.Stderr, action, convo.ID[:sha1minLen])
+	}
+	return nil
+}
+
 func deleteConversation(convo *Conversation) error {
 	if err := db.Delete(convo.ID); err != nil {
 		return modsError{err, "Couldn't delete conversation."}
@@ -612,7 +836,11 @@ func deleteConversation(convo *Conversation) error {
 }
 
 func listConversations(raw bool) error {
-	conversations, err := db.List()
+	list := db.List
+	if config.Favorites {
+		list = db.ListFavorites
+	}
+	conversations, err := list()
 	if err != nil {
 		return modsError{err, "Couldn't list saves."}
 	}
@@ -645,7 +873,7 @@ func roleNames(prefix string) []string {
 func listRoles() {
 	for _, role := range roleNames("") {
 		s := role
-		if role == config.Role {
+		if role == resolveRole(&config) {
 			s = role + stdoutStyles().Timeago.Render(" (default)")
 		}
 		fmt.Println(s)
@@ -657,6 +885,9 @@ func makeOptions(conversations []Conversation) []huh.Option[string] {
 	for _, c := range conversations {
 		timea := stdoutStyles().Timeago.Render(timeago.Of(c.UpdatedAt))
 		left := stdoutStyles().SHA1.Render(c.ID[:sha1short])
+		if c.Favorite {
+			left = stdoutStyles().Favorite.Render(favoriteStar) + " " + left
+		}
 		right := stdoutStyles().ConversationList.Render(c.Title, timea)
 		if c.Model != nil {
 			right += stdoutStyles().Comment.Render(*c.Model)
@@ -704,16 +935,66 @@ func selectFromList(conversations []Conversation) {
 
 func printList(conversations []Conversation) {
 	for _, conversation := range conversations {
+		// the favorite star goes last, so scripts reading the first columns
+		// keep working.
+		var favorite string
+		if conversation.Favorite {
+			favorite = "\t" + stdoutStyles().Favorite.Render(favoriteStar)
+		}
 		_, _ = fmt.Fprintf(
 			os.Stdout,
-			"%s\t%s\t%s\n",
+			"%s\t%s\t%s%s\n",
 			stdoutStyles().SHA1.Render(conversation.ID[:sha1short]),
 			conversation.Title,
 			stdoutStyles().Timeago.Render(timeago.Of(conversation.UpdatedAt)),
+			favorite,
 		)
 	}
 }
 
+// printOutput prints the output of the given run, unless it was already
+// printed in raw mode.
+func printOutput(mods *Mods) error {
+	if isOutputTTY() && !config.Raw {
+		output := mods.glamOutput
+		if output == "" {
+			output = mods.Output
+		}
+		if shouldPage(&config, output, mods.height) {
+			if err := page(output); err != nil {
+				return err
+			}
+		} else {
+			fmt.Print(output)
+		}
+	}
+
+	if mods.postProcessErr != nil && !config.Quiet {
+		fmt.Fprintf(
+			os.Stderr,
+			"\n%s %s\n",
+			stderrStyles().Comment.Render("Using the original response because the post-process-command failed:"),
+			mods.postProcessErr,
+		)
+	}
+
+	if report := mods.throughputReport(); report != "" && !config.Quiet {
+		fmt.Fprintf(os.Stderr, "\n%s\n", report)
+	}
+	return nil
+}
+
+// nextPrompt returns the prompt to continue the conversation with, if any.
+func nextPrompt(mods *Mods) (string, bool, error) {
+	if prompt, ok, err := choosePrompt(mods); err != nil || ok {
+		return prompt, ok, err
+	}
+	if prompt, ok, err := clarifyPrompt(mods); err != nil || ok {
+		return prompt, ok, err
+	}
+	return agentPrompt(mods)
+}
+
 func saveConversation(mods *Mods) error {
 	if config.NoCache {
 		if !config.Quiet {
@@ -741,6 +1022,12 @@ func saveConversation(mods *Mods) error {
 		stderrStyles().InlineCode.Render("--no-cache"),
 		stderrStyles().InlineCode.Render("NO_CACHE"),
 	)
+	if ok, err := underConversationsCap(db, &config, id); err != nil {
+		return modsError{err, errReason}
+	} else if !ok {
+		return conversationsCapReached()
+	}
+
 	cache, err := cache.NewConversations(config.CachePath)
 	if err != nil {
 		return modsError{err, errReason}
@@ -764,14 +1051,109 @@ func saveConversation(mods *Mods) error {
 	return nil
 }
 
+// validateSettings checks the settings that are only used to send prompts.
+//
+// It must be called after handling --dirs, --settings, and --reset-settings,
+// so invalid settings can still be fixed with them.
+func validateSettings() error {
+	if config.Pager != "" && !slices.Contains(pagerModes, config.Pager) {
+		return modsError{
+			err: newUserErrorf(
+				"Valid pager modes are: %s",
+				strings.Join(pagerModes, ", "),
+			),
+			reason: fmt.Sprintf("Invalid pager mode %q.", config.Pager),
+		}
+	}
+
+	if err := validatePools(config.Pools); err != nil {
+		return modsError{err, "Invalid pools in the settings."}
+	}
+
+	if err := validateSystemRoles(config.APIs); err != nil {
+		return modsError{err, "Invalid system role in the settings."}
+	}
+
+	if _, err := regexp.Compile(config.AgentStop); err != nil {
+		return modsError{err, fmt.Sprintf("Invalid agent stop condition %q.", config.AgentStop)}
+	}
+
+	if _, ok := config.Roles[roleNone]; ok {
+		return modsError{
+			err: newUserErrorf(
+				"Rename it in your settings, as %s sends no role at all.",
+				stderrStyles().InlineCode.Render("--role "+roleNone),
+			),
+			reason: fmt.Sprintf("The role name %q is reserved.", roleNone),
+		}
+	}
+
+	if config.Fence != "" && !slices.Contains(fenceModes, config.Fence) {
+		return modsError{
+			err: newUserErrorf(
+				"Valid fence modes are: %s",
+				strings.Join(fenceModes, ", "),
+			),
+			reason: fmt.Sprintf("Invalid fence mode %q.", config.Fence),
+		}
+	}
+
+	if config.IPVersion != "" && !slices.Contains(ipVersions, config.IPVersion) {
+		return modsError{
+			err: newUserErrorf(
+				"Valid IP versions are: %s",
+				strings.Join(ipVersions, ", "),
+			),
+			reason: fmt.Sprintf("Invalid IP version %q.", config.IPVersion),
+		}
+	}
+
+	if err := validatePenalties(config.FrequencyPenalty, config.PresencePenalty); err != nil {
+		return modsError{err, "Invalid penalty in the settings."}
+	}
+	for _, api := range config.APIs {
+		for name, mod := range api.Models {
+			if err := validatePenalties(mod.FrequencyPenalty, mod.PresencePenalty); err != nil {
+				return modsError{fmt.Errorf("%s/%s: %w", api.Name, name, err), "Invalid penalty in the settings."}
+			}
+		}
+	}
+	if err := validatePresets(config.Presets); err != nil {
+		return modsError{err, "Invalid sampling presets in the settings."}
+	}
+
+	if err := validateAttachPatterns(slices.Concat(config.AttachInclude, config.AttachExclude)); err != nil {
+		return err
+	}
+
+	if config.MaxConversationsAction != "" && !slices.Contains(conversationsCapActions, config.MaxConversationsAction) {
+		return modsError{
+			err: newUserErrorf(
+				"Valid actions are: %s",
+				strings.Join(conversationsCapActions, ", "),
+			),
+			reason: fmt.Sprintf("Invalid max conversations action %q.", config.MaxConversationsAction),
+		}
+	}
+
+	return nil
+}
+
 func isNoArgs() bool {
 	return config.Prefix == "" &&
+		config.PromptURL == "" &&
 		config.Show == "" &&
 		!config.ShowLast &&
 		len(config.Delete) == 0 &&
+		config.Favorite == "" &&
+		config.Unfavorite == "" &&
 		config.DeleteOlderThan == 0 &&
 		!config.ShowHelp &&
 		!config.List &&
+		!config.Browse &&
+		config.Reverify == "" &&
+		!config.RetryLast &&
+		!config.ForgetLast &&
 		!config.ListRoles &&
 		!config.MCPList &&
 		!config.MCPListTools &&
@@ -802,6 +1184,10 @@ func askInfo() error {
 		}
 	}
 
+	if _, ok := config.Pools[config.Model]; ok && !config.AskModel {
+		foundModel = true
+	}
+
 	if config.ContinueLast {
 		found, err := db.FindHEAD()
 		if err == nil && found != nil && found.Model != nil && found.API != nil {
@@ -847,9 +1233,19 @@ func askInfo() error {
 		}),
 	).
 		WithTheme(themeFrom(config.Theme)).
+		WithKeyMap(askInfoKeyMap()).
 		Run()
 }
 
+// askInfoKeyMap is the default keymap, but also allowing ctrl+d to submit
+// whatever was typed in the prompt.
+func askInfoKeyMap() *huh.KeyMap {
+	km := huh.NewDefaultKeyMap()
+	km.Text.Next = key.NewBinding(key.WithKeys("tab", "enter", "ctrl+d"), key.WithHelp("enter", "next"))
+	km.Text.Submit = key.NewBinding(key.WithKeys("enter", "ctrl+d"), key.WithHelp("enter", "submit"))
+	return km
+}
+
 //nolint:mnd
 func isManCmd(args []string) bool {
 	if len(args) == 2 {
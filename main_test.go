@@ -1,10 +1,21 @@
 package main
 
 import (
+	"log/slog"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
+// TestMain keeps the logs out of the output of the tests, the ones checking
+// them capture them.
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	os.Exit(m.Run())
+}
+
 func TestIsCompletionCmd(t *testing.T) {
 	for args, is := range map[string]bool{
 		"":                                     false,
@@ -60,3 +71,122 @@ func TestIsManCmd(t *testing.T) {
 		})
 	}
 }
+
+func TestFlagGroups(t *testing.T) {
+	saved := config
+	t.Cleanup(func() {
+		config = saved
+		rootCmd.ResetFlags()
+	})
+	for args, valid := range map[string]bool{
+		"-c foo -t bar":                 true,
+		"-C -t bar":                     true,
+		"--continue-file x.json":        true,
+		"--continue-file x.json -c foo": false,
+		"--continue-file x.json -C":     false,
+		"--continue-file x.json -t bar": false,
+		"--continue-file x.json -s foo": false,
+		"-c foo -C":                     false,
+	} {
+		t.Run(args, func(t *testing.T) {
+			rootCmd.ResetFlags()
+			initFlags()
+			if err := rootCmd.ParseFlags(strings.Fields(args)); err != nil {
+				t.Fatal(err)
+			}
+			if err := rootCmd.ValidateFlagGroups(); (err == nil) != valid {
+				t.Errorf("%s: expected valid to be %v, got %v", args, valid, err)
+			}
+		})
+	}
+}
+
+func TestPrintList(t *testing.T) {
+	stdout := os.Stdout
+	t.Cleanup(func() { os.Stdout = stdout })
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = f
+
+	printList([]Conversation{
+		{ID: "df31ae23ab8b75b5643c2f846c570997edc71333", Title: "naturals", Favorite: true, UpdatedAt: time.Now()},
+		{ID: "7d1e0d6e1ab5c4d7e5bbcf2d5e3b5c1a9b4c0e11", Title: "primes", UpdatedAt: time.Now()},
+	})
+	_ = f.Close()
+	bts, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(bts)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+	for i, expect := range [][]string{
+		{"df31ae2", "naturals", favoriteStar},
+		{"7d1e0d6", "primes"},
+	} {
+		fields := strings.Split(lines[i], "\t")
+		if len(fields) != len(expect)+1 {
+			t.Fatalf("line %d: expected %d columns, got %q", i, len(expect)+1, fields)
+		}
+		if fields[0] != expect[0] || fields[1] != expect[1] {
+			t.Errorf("line %d: expected ID and title %q, got %q", i, expect[:2], fields[:2])
+		}
+		if len(expect) == 3 && fields[3] != expect[2] {
+			t.Errorf("line %d: expected the star last, got %q", i, fields[3])
+		}
+	}
+}
+
+func TestValidateSettingsPager(t *testing.T) {
+	saved := config
+	t.Cleanup(func() { config = saved })
+
+	for pager, valid := range map[string]bool{
+		"":          true,
+		pagerAuto:   true,
+		pagerAlways: true,
+		pagerNever:  true,
+		"sometimes": false,
+	} {
+		t.Run(pager, func(t *testing.T) {
+			config = Config{Pager: pager}
+			if err := validateSettings(); (err == nil) != valid {
+				t.Errorf("%q: expected valid to be %v, got %v", pager, valid, err)
+			}
+		})
+	}
+}
+
+func TestValidateSettingsPresets(t *testing.T) {
+	saved := config
+	t.Cleanup(func() { config = saved })
+
+	config = Config{Presets: defaultPresets()}
+	if err := validateSettings(); err != nil {
+		t.Errorf("expected the default presets to be valid, got %v", err)
+	}
+
+	config = Config{Presets: map[string]Preset{"hot": {Temperature: ptr(3.0)}}}
+	if err := validateSettings(); err == nil {
+		t.Error("expected a preset with temp 3.0 to be invalid")
+	}
+}
+
+func TestValidateSettingsRoleNone(t *testing.T) {
+	saved := config
+	t.Cleanup(func() { config = saved })
+
+	config = Config{Roles: map[string][]string{"default": {}, "shell": {"you are a shell expert"}}}
+	if err := validateSettings(); err != nil {
+		t.Errorf("expected the roles to be valid, got %v", err)
+	}
+
+	config = Config{Roles: map[string][]string{roleNone: {"you are nobody"}}}
+	if err := validateSettings(); err == nil {
+		t.Errorf("expected a role named %q to be invalid", roleNone)
+	}
+}
@@ -33,8 +33,23 @@ func enabledMCPs() iter.Seq2[string, MCPServerConfig] {
 }
 
 func isMCPEnabled(name string) bool {
-	return !slices.Contains(config.MCPDisable, "*") &&
-		!slices.Contains(config.MCPDisable, name)
+	return isMCPEnabledIn(&config, name)
+}
+
+func isMCPEnabledIn(cfg *Config, name string) bool {
+	return !slices.Contains(cfg.MCPDisable, "*") &&
+		!slices.Contains(cfg.MCPDisable, name)
+}
+
+// anyMCPEnabled returns whether any of the MCP servers in the given settings
+// is enabled, so the model could call tools.
+func anyMCPEnabled(cfg *Config) bool {
+	for name := range cfg.MCPServers {
+		if isMCPEnabledIn(cfg, name) {
+			return true
+		}
+	}
+	return false
 }
 
 func mcpList() {
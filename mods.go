@@ -6,10 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"maps"
 	"math"
 	"net/http"
-	"net/url"
 	"os"
 	"os/exec"
 	"regexp"
@@ -17,6 +17,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unicode"
 
@@ -75,6 +76,52 @@ type Mods struct {
 	content      []string
 	contentMutex *sync.Mutex
 
+	// history and followUp are set when continuing a conversation in the
+	// same run, see [Mods.continueWith].
+	history  []proto.Message
+	followUp string
+	// step is the number of times the conversation was continued in the same
+	// run, starting at 0.
+	step int
+
+	postProcessed  bool
+	postProcessErr error
+
+	throughput throughput
+	lineBuf    lineBuffer
+	trim       edgeTrimmer
+	strip      *tokenStripper
+	stamp      *timestamper
+
+	// prompt is the assembled prompt, set instead of sending it with
+	// --print-prompt.
+	prompt string
+
+	// failedEndpoints are the pool endpoints that failed in this run.
+	failedEndpoints map[string]bool
+
+	// sent is whether a prompt was sent, so the command can be retried with
+	// --retry-last.
+	sent bool
+
+	// model is the model the last request was sent to, as resolved when it
+	// was sent, e.g. with the endpoint picked from its pool.
+	model Model
+
+	// turnStart is where the output of the current completion starts.
+	turnStart int
+	// toolsEnabled is whether the model could call tools, and toolsChecked
+	// whether its answer was checked for made up tool results, with
+	// --strict-tools.
+	toolsEnabled  bool
+	toolsChecked  bool
+	strictRetries int
+
+	// attached are the files found under --attach-dir, and attachMaxChars
+	// the max-input-chars they were attached under, see [Mods.attachDirs].
+	attached       []attachedFile
+	attachMaxChars int64
+
 	ctx context.Context
 }
 
@@ -101,6 +148,7 @@ func newMods(
 		db:           db,
 		cache:        cache,
 		Config:       cfg,
+		stamp:        newTimestamper(cfg),
 		ctx:          ctx,
 	}
 }
@@ -110,6 +158,11 @@ type completionInput struct {
 	content string
 }
 
+// promptMsg is a tea.Msg that wraps the assembled prompt, with --print-prompt.
+type promptMsg struct {
+	content string
+}
+
 // completionOutput a tea.Msg that wraps the content returned from openai.
 type completionOutput struct {
 	content string
@@ -119,9 +172,35 @@ type completionOutput struct {
 
 // Init implements tea.Model.
 func (m *Mods) Init() tea.Cmd {
+	if m.history != nil {
+		return m.followUpDetails
+	}
 	return m.findCacheOpsDetails()
 }
 
+// continueWith creates a new [Mods] that continues the conversation of the
+// current one with the given prompt.
+func (m *Mods) continueWith(prompt string) *Mods {
+	next := newMods(m.ctx, m.renderer, m.Config, m.db, m.cache)
+	next.history = m.messages
+	next.followUp = prompt
+	next.step = m.step + 1
+	next.failedEndpoints = m.failedEndpoints
+	return next
+}
+
+// followUpDetails keeps the cache details from the previous run, as we are
+// still on the same conversation.
+func (m *Mods) followUpDetails() tea.Msg {
+	return cacheDetailsMsg{
+		WriteID: m.Config.cacheWriteToID,
+		Title:   m.Config.cacheWriteToTitle,
+		ReadID:  m.Config.cacheReadFromID,
+		API:     m.Config.API,
+		Model:   m.Config.Model,
+	}
+}
+
 // Update implements tea.Model.
 func (m *Mods) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
@@ -138,18 +217,24 @@ func (m *Mods) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, m.anim.Init())
 		}
 		m.state = configLoadedState
-		cmds = append(cmds, m.readStdinCmd)
+		if m.history != nil {
+			cmds = append(cmds, func() tea.Msg { return completionInput{m.followUp} })
+		} else {
+			cmds = append(cmds, m.readStdinCmd)
+		}
 
 	case completionInput:
 		if msg.content != "" {
 			m.Input = removeWhitespace(msg.content)
 		}
-		if m.Input == "" && m.Config.Prefix == "" && m.Config.Show == "" && !m.Config.ShowLast {
+		if m.Input == "" && m.Config.Prefix == "" && m.Config.PromptURL == "" && m.Config.Show == "" && !m.Config.ShowLast {
 			return m, m.quit
 		}
 		if m.Config.Dirs ||
 			len(m.Config.Delete) > 0 ||
 			m.Config.DeleteOlderThan != 0 ||
+			m.Config.Favorite != "" ||
+			m.Config.Unfavorite != "" ||
 			m.Config.ShowHelp ||
 			m.Config.List ||
 			m.Config.ListRoles ||
@@ -157,12 +242,21 @@ func (m *Mods) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.Config.ResetSettings {
 			return m, m.quit
 		}
+		if m.Config.PrintPrompt {
+			return m, m.assemblePromptCmd(msg.content)
+		}
+
+		// this is worked out before appending anything, so holdOutput knows
+		// what to hold, and the prompt echoed below is printed as is, as
+		// only the answer is checked.
+		m.toolsEnabled = anyMCPEnabled(m.Config)
+		m.toolsChecked = true
 
-		if m.Config.IncludePromptArgs {
+		if m.Config.IncludePromptArgs && m.history == nil {
 			m.appendToOutput(m.Config.Prefix + "\n\n")
 		}
 
-		if m.Config.IncludePrompt > 0 {
+		if m.Config.IncludePrompt > 0 && m.history == nil {
 			parts := strings.Split(m.Input, "\n")
 			if len(parts) > m.Config.IncludePrompt {
 				parts = parts[0:m.Config.IncludePrompt]
@@ -170,20 +264,53 @@ func (m *Mods) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.appendToOutput(strings.Join(parts, "\n") + "\n")
 		}
 		m.state = requestState
+		m.throughput = throughput{start: time.Now()}
+		m.startTurnOutput()
+		m.toolsChecked, m.strictRetries = false, 0
 		cmds = append(cmds, m.startCompletionCmd(msg.content))
 	case completionOutput:
 		if msg.stream == nil {
+			if rest := m.strip.flush(); rest != "" {
+				m.appendToOutput(rest)
+			}
+			if n := len(m.messages); n > 0 && m.messages[n-1].Role == proto.RoleAssistant {
+				m.messages[n-1].Content = m.strip.strip(m.messages[n-1].Content)
+				if m.trimOutput() {
+					m.messages[n-1].Content = trimEdges(m.messages[n-1].Content)
+				}
+			}
+			if cmd, ok := m.rejectFakeToolResults(); ok {
+				return m, cmd
+			}
+			if m.shouldPostProcess() {
+				return m, m.postProcessCmd
+			}
+			if rest := m.lineBuf.flush(); rest != "" {
+				m.contentMutex.Lock()
+				m.content = append(m.content, m.stamp.write(rest))
+				m.contentMutex.Unlock()
+			}
 			m.state = doneState
 			return m, m.quit
 		}
 		if msg.content != "" {
-			m.appendToOutput(msg.content)
+			m.throughput.observe(msg.content)
+			if content := m.strip.write(msg.content); content != "" {
+				m.appendToOutput(content)
+			}
 			m.state = responseState
 		}
 		cmds = append(cmds, m.receiveCompletionStreamCmd(completionOutput{
 			stream: msg.stream,
 			errh:   msg.errh,
 		}))
+	case postProcessedMsg:
+		m.applyPostProcessed(msg)
+		m.state = responseState
+		return m, func() tea.Msg { return completionOutput{} }
+	case promptMsg:
+		m.prompt = msg.content
+		return m, m.quit
 	case modsError:
 		m.Error = &msg
 		m.state = errorState
@@ -242,21 +369,32 @@ func (m *Mods) View() string {
 			return m.Output
 		}
 
-		m.contentMutex.Lock()
-		for _, c := range m.content {
-			fmt.Print(c)
-		}
-		m.content = []string{}
-		m.contentMutex.Unlock()
+		m.printContent()
 	case doneState:
 		if !isOutputTTY() {
+			m.printContent()
 			fmt.Printf("\n")
+		} else if m.Config.Raw {
+			// print what was held back until the end, e.g. the last line
+			// with --line-buffered.
+			m.printContent()
 		}
 		return ""
 	}
 	return ""
 }
 
+// printContent prints the content received since the last time it was
+// called, used when not rendering the output.
+func (m *Mods) printContent() {
+	m.contentMutex.Lock()
+	for _, c := range m.content {
+		fmt.Print(c)
+	}
+	m.content = []string{}
+	m.contentMutex.Unlock()
+}
+
 func (m *Mods) quit() tea.Msg {
 	for _, cancel := range m.cancelRequest {
 		cancel()
@@ -267,17 +405,23 @@ func (m *Mods) quit() tea.Msg {
 func (m *Mods) retry(content string, err modsError) tea.Msg {
 	m.retries++
 	if m.retries >= m.Config.MaxRetries {
+		slog.Debug("giving up", "retries", m.retries, "reason", err.reason, "err", err.err)
 		return err
 	}
 	wait := time.Millisecond * 100 * time.Duration(math.Pow(2, float64(m.retries))) //nolint:mnd
+	slog.Debug("retrying", "attempt", m.retries, "wait", wait, "reason", err.reason, "err", err.err)
 	time.Sleep(wait)
 	return completionInput{content}
 }
 
+// streamBufferSize is how many chunks are read ahead of the output.
+const streamBufferSize = 64
+
 func (m *Mods) startCompletionCmd(content string) tea.Cmd {
 	if m.Config.Show != "" || m.Config.ShowLast {
 		return m.readFromCache()
 	}
+	m.sent = true
 
 	return func() tea.Msg {
 		var mod Model
@@ -294,6 +438,7 @@ func (m *Mods) startCompletionCmd(content string) tea.Cmd {
 		if err != nil {
 			return err
 		}
+		setLogAttrs(mod.API, cfg.cacheWriteToID)
 		if api.Name == "" {
 			eps := make([]string, 0)
 			for _, a := range cfg.APIs {
@@ -311,6 +456,15 @@ func (m *Mods) startCompletionCmd(content string) tea.Cmd {
 			}
 		}
 
+		if err := checkOrganization(api, mod.API); err != nil {
+			return err
+		}
+
+		httpClient, err := apiHTTPClient(cfg)
+		if err != nil {
+			return modsError{err, "There was an error parsing your proxy URL."}
+		}
+
 		switch mod.API {
 		case "ollama":
 			occfg = ollama.DefaultConfig()
@@ -358,7 +512,7 @@ func (m *Mods) startCompletionCmd(content string) tea.Cmd {
 				cfg.User = api.User
 			}
 		case "copilot":
-			cli := copilot.New(config.CachePath)
+			cli := copilot.New(config.CachePath, httpClient)
 			token, err := cli.Auth()
 			if err != nil {
 				return modsError{err, "Copilot authentication failed"}
@@ -377,21 +531,36 @@ func (m *Mods) startCompletionCmd(content string) tea.Cmd {
 				return modsError{err, "OpenAI authentication failed"}
 			}
 			ccfg = openai.Config{
-				AuthToken: key,
-				BaseURL:   api.BaseURL,
+				AuthToken:    key,
+				BaseURL:      api.BaseURL,
+				Organization: api.Organization,
+				Project:      api.Project,
 			}
 		}
 
-		if cfg.HTTPProxy != "" {
-			proxyURL, err := url.Parse(cfg.HTTPProxy)
-			if err != nil {
-				return modsError{err, "There was an error parsing your proxy URL."}
+		if httpClient != nil {
+			if mod.API != "copilot" {
+				// the copilot client already uses it.
+				ccfg.HTTPClient = httpClient
 			}
-			httpClient := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
-			ccfg.HTTPClient = httpClient
 			accfg.HTTPClient = httpClient
 			cccfg.HTTPClient = httpClient
 			occfg.HTTPClient = httpClient
+			gccfg.HTTPClient = httpClient
+		}
+
+		accfg.HTTPClient = debugHTTPClient(m.ctx, accfg.HTTPClient)
+		cccfg.HTTPClient = debugHTTPClient(m.ctx, cccfg.HTTPClient)
+		occfg.HTTPClient = debugHTTPClient(m.ctx, occfg.HTTPClient)
+		gccfg.HTTPClient = debugHTTPClient(m.ctx, gccfg.HTTPClient)
+		switch c := ccfg.HTTPClient.(type) {
+		case nil:
+			// don't set a nil *http.Client, as the interface wouldn't be nil.
+			if c := debugHTTPClient(m.ctx, nil); c != nil {
+				ccfg.HTTPClient = c
+			}
+		case *http.Client:
+			ccfg.HTTPClient = debugHTTPClient(m.ctx, c)
 		}
 
 		if mod.MaxChars == 0 {
@@ -437,6 +606,19 @@ func (m *Mods) startCompletionCmd(content string) tea.Cmd {
 		if cfg.MaxTokens > 0 {
 			request.MaxTokens = &cfg.MaxTokens
 		}
+		request.SystemRole = ordered.First(mod.SystemRole, api.SystemRole)
+		request.ParallelToolCalls = mod.ParallelToolCalls
+		if cfg.ParallelToolCalls != nil {
+			request.ParallelToolCalls = cfg.ParallelToolCalls
+		}
+		request.FrequencyPenalty = mod.FrequencyPenalty
+		if cfg.FrequencyPenalty != nil {
+			request.FrequencyPenalty = cfg.FrequencyPenalty
+		}
+		request.PresencePenalty = mod.PresencePenalty
+		if cfg.PresencePenalty != nil {
+			request.PresencePenalty = cfg.PresencePenalty
+		}
 
 		var client stream.Client
 		switch mod.API {
@@ -458,7 +640,10 @@ func (m *Mods) startCompletionCmd(content string) tea.Cmd {
 			return modsError{err, "Could not setup client"}
 		}
 
-		stream := client.Request(m.ctx, request)
+		m.strip = newTokenStripper(stripTokens(mod))
+		m.model = mod
+		bugReport.setRequest(request)
+		stream := stream.Buffered(client.Request(m.ctx, request), streamBufferSize)
 		return m.receiveCompletionStreamCmd(completionOutput{
 			stream: stream,
 			errh: func(err error) tea.Msg {
@@ -468,8 +653,74 @@ func (m *Mods) startCompletionCmd(content string) tea.Cmd {
 	}
 }
 
+// assemblePromptCmd assembles the prompt as it would be sent, without sending
+// it.
+func (m *Mods) assemblePromptCmd(content string) tea.Cmd {
+	return func() tea.Msg {
+		_, mod, err := m.resolveModel(m.Config)
+		if err != nil {
+			return err
+		}
+		if mod.MaxChars == 0 {
+			mod.MaxChars = m.Config.MaxInputChars
+		}
+		if err := m.setupStreamContext(content, mod); err != nil {
+			return err
+		}
+		return promptMsg{m.messages[len(m.messages)-1].Content}
+	}
+}
+
+// checkOrganization errors if the organization or project are set for an API
+// that does not accept them, which is everything but OpenAI compatible APIs.
+func checkOrganization(api API, name string) error {
+	if api.Organization == "" && api.Project == "" {
+		return nil
+	}
+	switch name {
+	case "ollama", "anthropic", "google", "cohere", "azure", "azure-ad", "copilot":
+		return modsError{
+			err: newUserErrorf(
+				"Remove %s and %s from the %s API settings.",
+				stderrStyles().InlineCode.Render("organization"),
+				stderrStyles().InlineCode.Render("project"),
+				name,
+			),
+			reason: fmt.Sprintf("The %s API does not support organizations nor projects.", name),
+		}
+	}
+	return nil
+}
+
+var systemRoles = []string{proto.RoleSystem, proto.RoleDeveloper}
+
+// validateSystemRoles errors if any API or model has an unknown system-role.
+func validateSystemRoles(apis APIs) error {
+	valid := func(role string) bool {
+		return role == "" || slices.Contains(systemRoles, role)
+	}
+	for _, api := range apis {
+		if !valid(api.SystemRole) {
+			return fmt.Errorf("api %q has an invalid system-role %q, valid ones are: %s", api.Name, api.SystemRole, strings.Join(systemRoles, ", "))
+		}
+		for name, mod := range api.Models {
+			if !valid(mod.SystemRole) {
+				return fmt.Errorf("model %q of api %q has an invalid system-role %q, valid ones are: %s", name, api.Name, mod.SystemRole, strings.Join(systemRoles, ", "))
+			}
+		}
+	}
+	return nil
+}
+
 func (m Mods) ensureKey(api API, defaultEnv, docsURL string) (string, error) {
 	key := api.APIKey
+	if key == "" && api.APIKeyFile != "" {
+		var err error
+		key, err = readKeyFile(api.APIKeyFile)
+		if err != nil {
+			return "", err
+		}
+	}
 	if key == "" && api.APIKeyEnv != "" && api.APIKeyCmd == "" {
 		key = os.Getenv(api.APIKeyEnv)
 	}
@@ -504,6 +755,34 @@ func (m Mods) ensureKey(api API, defaultEnv, docsURL string) (string, error) {
 	}
 }
 
+var (
+	keyFiles   = map[string]string{}
+	keyFilesMu sync.Mutex
+)
+
+// readKeyFile reads an API key from the given file (e.g. a Docker secret),
+// caching the result so retries don't read it again.
+func readKeyFile(path string) (string, error) {
+	keyFilesMu.Lock()
+	defer keyFilesMu.Unlock()
+	if key, ok := keyFiles[path]; ok {
+		return key, nil
+	}
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return "", modsError{err, "Cannot read api-key-file"}
+	}
+	key := strings.TrimSpace(string(bts))
+	if key == "" {
+		return "", modsError{
+			err:    fmt.Errorf("%s is empty", path),
+			reason: "Cannot read api-key-file",
+		}
+	}
+	keyFiles[path] = key
+	return key, nil
+}
+
 func (m *Mods) receiveCompletionStreamCmd(msg completionOutput) tea.Cmd {
 	return func() tea.Msg {
 		if msg.stream.Next() {
@@ -619,16 +898,54 @@ func (m *Mods) findReadID(in string) (*Conversation, error) {
 func (m *Mods) readStdinCmd() tea.Msg {
 	if !isInputTTY() {
 		reader := bufio.NewReader(os.Stdin)
+		if m.Config.StdinTimeout > 0 && !waitForInput(reader, m.Config.StdinTimeout) {
+			stdinAbandoned.Store(true)
+			return completionInput{""}
+		}
 		stdinBytes, err := io.ReadAll(reader)
 		if err != nil {
 			return modsError{err, "Unable to read stdin."}
 		}
+		stdin := string(stdinBytes)
+		stdinSnapshot.Store(&stdin)
+
+		if format := m.Config.InputFormat; format != "" && format != inputFormatText {
+			input, err := formatInput(format, string(stdinBytes))
+			if err != nil {
+				return modsError{err, fmt.Sprintf("Unable to parse stdin as %s.", format)}
+			}
+			return completionInput{input}
+		}
+
+		if fenced := fenceInput(m.Config.Fence, m.Config.FenceLang, string(stdinBytes)); fenced != string(stdinBytes) {
+			return completionInput{fenced}
+		}
 
 		return completionInput{increaseIndent(string(stdinBytes))}
 	}
 	return completionInput{""}
 }
 
+// stdinAbandoned is set when we gave up waiting for STDIN, in which case it
+// should not be read again.
+var stdinAbandoned atomic.Bool
+
+// waitForInput waits up to the given timeout for the reader to have any
+// data, or to reach EOF.
+func waitForInput(r *bufio.Reader, timeout time.Duration) bool {
+	ready := make(chan struct{})
+	go func() {
+		_, _ = r.Peek(1)
+		close(ready)
+	}()
+	select {
+	case <-ready:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 func (m *Mods) readFromCache() tea.Cmd {
 	return func() tea.Msg {
 		var messages []proto.Message
@@ -636,7 +953,11 @@ func (m *Mods) readFromCache() tea.Cmd {
 			return modsError{err, "There was an error loading the conversation."}
 		}
 
-		m.appendToOutput(proto.Conversation(messages).String())
+		if label := m.assistantLabel(); label != "" {
+			m.appendToOutput(proto.Conversation(messages).WithAssistantLabel(label))
+		} else {
+			m.appendToOutput(proto.Conversation(messages).String())
+		}
 		return completionOutput{
 			errh: func(err error) tea.Msg {
 				return modsError{err: err}
@@ -648,19 +969,55 @@ func (m *Mods) readFromCache() tea.Cmd {
 const tabWidth = 4
 
 func (m *Mods) appendToOutput(s string) {
+	if m.trimOutput() {
+		if s = m.trim.write(s); s == "" {
+			return
+		}
+	}
+	m.writeOutput(s)
+}
+
+// startTurnOutput makes the output of the current completion start here, so
+// its edges are trimmed on their own, rather than as the continuation of the
+// prompt echoed before it.
+func (m *Mods) startTurnOutput() {
+	if held := m.trim.restart(); held != "" {
+		m.writeOutput(held)
+	}
+	m.turnStart = len(m.Output)
+}
+
+// writeOutput adds the given content to the output, as is.
+func (m *Mods) writeOutput(s string) {
 	m.Output += s
 	if !isOutputTTY() || m.Config.Raw {
+		if m.holdOutput() {
+			// only print it once it has been post-processed or checked.
+			return
+		}
+		if m.lineBuffered() {
+			if s = m.lineBuf.write(s); s == "" {
+				return
+			}
+		}
 		m.contentMutex.Lock()
-		m.content = append(m.content, s)
+		m.content = append(m.content, m.stamp.write(s))
 		m.contentMutex.Unlock()
 		return
 	}
 
 	wasAtBottom := m.glamViewport.ScrollPercent() == 1.0
 	oldHeight := m.glamHeight
-	m.glamOutput, _ = m.glam.Render(m.Output)
+	output := m.Output
+	if !m.Config.NoAutolang {
+		output = autolang(output)
+	}
+	m.glamOutput, _ = m.glam.Render(output)
 	m.glamOutput = strings.TrimRightFunc(m.glamOutput, unicode.IsSpace)
 	m.glamOutput = strings.ReplaceAll(m.glamOutput, "\t", strings.Repeat(" ", tabWidth))
+	if label := m.assistantLabelView(); label != "" && m.Config.Show == "" && !m.Config.ShowLast {
+		m.glamOutput = label + "\n" + m.glamOutput
+	}
 	m.glamHeight = lipgloss.Height(m.glamOutput)
 	m.glamOutput += "\n"
 	truncatedGlamOutput := m.renderer.NewStyle().
@@ -717,6 +1074,9 @@ func increaseIndent(s string) string {
 }
 
 func (m *Mods) resolveModel(cfg *Config) (API, Model, error) {
+	if endpoints, ok := cfg.Pools[cfg.Model]; ok {
+		return m.resolvePool(cfg, cfg.Model, endpoints)
+	}
 	for _, api := range cfg.APIs {
 		if api.Name != cfg.API && cfg.API != "" {
 			continue
@@ -3,16 +3,20 @@ package main
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/openai/openai-go"
+	"github.com/charmbracelet/mods/internal/proto"
 )
 
 func (m *Mods) handleRequestError(err error, mod Model, content string) tea.Msg {
-	ae := &openai.Error{}
-	if errors.As(err, &ae) {
-		return m.handleAPIError(ae, mod, content)
+	if msg, ok := m.failover(err, mod, content); ok {
+		return msg
+	}
+	var perr *proto.ProviderError
+	if errors.As(err, &perr) {
+		return m.handleAPIError(perr, mod, content)
 	}
 	return modsError{err, fmt.Sprintf(
 		"There was a problem with the %s API request.",
@@ -20,11 +24,12 @@ func (m *Mods) handleRequestError(err error, mod Model, content string) tea.Msg
 	)}
 }
 
-func (m *Mods) handleAPIError(err *openai.Error, mod Model, content string) tea.Msg {
+func (m *Mods) handleAPIError(err *proto.ProviderError, mod Model, content string) tea.Msg {
 	cfg := m.Config
 	switch err.StatusCode {
 	case http.StatusNotFound:
 		if mod.Fallback != "" {
+			slog.Info("model not found, using fallback", "model", mod.Name, "fallback", mod.Fallback)
 			m.Config.Model = mod.Fallback
 			return m.retry(content, modsError{
 				err:    err,
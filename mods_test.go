@@ -2,7 +2,10 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -220,3 +223,166 @@ func TestCutPrompt(t *testing.T) {
 		})
 	}
 }
+
+func TestReadKeyFile(t *testing.T) {
+	t.Run("trims", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "openai_key")
+		require.NoError(t, os.WriteFile(path, []byte("  sk-secret\n"), 0o600))
+		key, err := readKeyFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "sk-secret", key)
+
+		// cached, so it's not read again.
+		require.NoError(t, os.Remove(path))
+		key, err = readKeyFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "sk-secret", key)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := readKeyFile(filepath.Join(t.TempDir(), "nope"))
+		require.ErrorIs(t, err.(modsError).err, os.ErrNotExist)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "empty")
+		require.NoError(t, os.WriteFile(path, []byte("\n"), 0o600))
+		_, err := readKeyFile(path)
+		require.EqualError(t, err, path+" is empty")
+	})
+}
+
+func TestCheckOrganization(t *testing.T) {
+	t.Run("not set", func(t *testing.T) {
+		require.NoError(t, checkOrganization(API{}, "anthropic"))
+	})
+	t.Run("openai", func(t *testing.T) {
+		require.NoError(t, checkOrganization(API{Organization: "org-1", Project: "proj_1"}, "openai"))
+	})
+	t.Run("openai compatible", func(t *testing.T) {
+		require.NoError(t, checkOrganization(API{Project: "proj_1"}, "groq"))
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		err := checkOrganization(API{Organization: "org-1"}, "anthropic")
+		require.Error(t, err)
+		require.Equal(t, "The anthropic API does not support organizations nor projects.", err.(modsError).reason)
+	})
+}
+
+func TestValidateSystemRoles(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		require.NoError(t, validateSystemRoles(APIs{
+			{Name: "openai", SystemRole: "developer", Models: map[string]Model{
+				"gpt-4o": {SystemRole: "system"},
+				"o3":     {},
+			}},
+			{Name: "anthropic"},
+		}))
+	})
+	t.Run("invalid api", func(t *testing.T) {
+		err := validateSystemRoles(APIs{{Name: "openai", SystemRole: "admin"}})
+		require.ErrorContains(t, err, `api "openai" has an invalid system-role "admin"`)
+	})
+	t.Run("invalid model", func(t *testing.T) {
+		err := validateSystemRoles(APIs{{Name: "openai", Models: map[string]Model{
+			"o3": {SystemRole: "Developer"},
+		}}})
+		require.ErrorContains(t, err, `model "o3" of api "openai" has an invalid system-role "Developer"`)
+	})
+}
+
+func TestAssemblePromptCmd(t *testing.T) {
+	newMods := func() *Mods {
+		return &Mods{
+			Config: &Config{
+				API:    "openai",
+				Model:  "4o",
+				Prefix: "explain this",
+				Role:   "shell",
+				Roles: map[string][]string{
+					"shell": {"you are a shell expert"},
+				},
+				APIs: APIs{{
+					Name: "openai",
+					Models: map[string]Model{
+						"gpt-4o": {Aliases: []string{"4o"}, MaxChars: 20},
+					},
+				}},
+			},
+		}
+	}
+
+	t.Run("prompt only", func(t *testing.T) {
+		msg := newMods().assemblePromptCmd("ls -la")()
+		require.Equal(t, promptMsg{"explain this\n\nls -la"}, msg)
+	})
+
+	t.Run("truncated to the model max chars", func(t *testing.T) {
+		msg := newMods().assemblePromptCmd("ls -la /usr/local/bin")()
+		require.Equal(t, promptMsg{"explain this\n\nls -la"}, msg)
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		m := newMods()
+		m.Config.NoLimit = true
+		msg := m.assemblePromptCmd("ls -la /usr/local/bin")()
+		require.Equal(t, promptMsg{"explain this\n\nls -la /usr/local/bin"}, msg)
+	})
+
+	t.Run("unknown model", func(t *testing.T) {
+		m := newMods()
+		m.Config.Model = "nope"
+		_, ok := m.assemblePromptCmd("ls")().(modsError)
+		require.True(t, ok)
+	})
+}
+
+func TestReadStdinCmdTimeout(t *testing.T) {
+	tty, stdin := isInputTTY, os.Stdin
+	t.Cleanup(func() {
+		isInputTTY, os.Stdin = tty, stdin
+		stdinAbandoned.Store(false)
+		stdinSnapshot.Store(nil)
+	})
+	isInputTTY = func() bool { return false }
+
+	read := func(t *testing.T, m *Mods) any {
+		t.Helper()
+		msgs := make(chan any, 1)
+		go func() { msgs <- m.readStdinCmd() }()
+		select {
+		case msg := <-msgs:
+			return msg
+		case <-time.After(5 * time.Second):
+			t.Fatal("reading stdin blocked past the timeout")
+			return nil
+		}
+	}
+
+	t.Run("nothing is written", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		// closed last, so the reader given up on is unblocked.
+		t.Cleanup(func() { _ = w.Close(); _ = r.Close() })
+		os.Stdin = r
+
+		msg := read(t, &Mods{Config: &Config{StdinTimeout: 50 * time.Millisecond}})
+		require.Equal(t, completionInput{""}, msg)
+		require.True(t, stdinAbandoned.Load())
+	})
+
+	t.Run("written in time", func(t *testing.T) {
+		stdinAbandoned.Store(false)
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = r.Close() })
+		os.Stdin = r
+		_, err = w.WriteString("first 2 primes")
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		msg := read(t, &Mods{Config: &Config{StdinTimeout: time.Second}})
+		require.Equal(t, completionInput{increaseIndent("first 2 primes")}, msg)
+		require.False(t, stdinAbandoned.Load())
+	})
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/caarlos0/go-shellwords"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	pagerAuto   = "auto"
+	pagerAlways = "always"
+	pagerNever  = "never"
+)
+
+var pagerModes = []string{pagerAuto, pagerAlways, pagerNever}
+
+const defaultPager = "less -R"
+
+// shouldPage returns whether the given output should be sent to the pager,
+// given the current settings and the terminal height.
+func shouldPage(cfg *Config, output string, height int) bool {
+	if cfg.NoPager || cfg.Raw || !isOutputTTY() {
+		return false
+	}
+	switch cfg.Pager {
+	case pagerAlways:
+		return true
+	case pagerAuto:
+		return height > 0 && lipgloss.Height(output) > height
+	default:
+		return false
+	}
+}
+
+// page writes the given output into the user's $PAGER.
+//
+// If the pager exits before consuming all the output (e.g. the user quit
+// `less` before reaching the end), the broken pipe is not treated as an error.
+func page(output string) error {
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = defaultPager
+	}
+	args, err := shellwords.Parse(pager)
+	if err != nil || len(args) == 0 {
+		return modsError{err, fmt.Sprintf("Could not parse %s.", stderrStyles().InlineCode.Render("$PAGER"))}
+	}
+
+	cmd := exec.Command(args[0], args[1:]...) //nolint:gosec
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		// same defaults as git: quit if one screen, keep colors, don't clear.
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return modsError{err, "Could not start the pager."}
+	}
+	if err := cmd.Start(); err != nil {
+		return modsError{err, "Could not start the pager."}
+	}
+
+	// A write error here usually means the pager exited before reading
+	// everything, which is fine as long as it exited successfully.
+	_, _ = io.WriteString(stdin, output)
+	_ = stdin.Close()
+
+	if err := cmd.Wait(); err != nil {
+		return modsError{err, "The pager exited with an error."}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldPage(t *testing.T) {
+	tty := isOutputTTY
+	t.Cleanup(func() { isOutputTTY = tty })
+	isOutputTTY = func() bool { return true }
+
+	short := "one\ntwo"
+	long := strings.Repeat("line\n", 30)
+
+	for name, tc := range map[string]struct {
+		cfg    Config
+		output string
+		expect bool
+	}{
+		"always":              {Config{Pager: pagerAlways}, short, true},
+		"never":               {Config{Pager: pagerNever}, long, false},
+		"auto, fits":          {Config{Pager: pagerAuto}, short, false},
+		"auto, as tall":       {Config{Pager: pagerAuto}, strings.Repeat("line\n", 9) + "line", false},
+		"auto, taller":        {Config{Pager: pagerAuto}, long, true},
+		"no pager":            {Config{Pager: pagerAlways, NoPager: true}, long, false},
+		"raw":                 {Config{Pager: pagerAlways, Raw: true}, long, false},
+		"empty mode is never": {Config{}, long, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expect, shouldPage(&tc.cfg, tc.output, 10))
+		})
+	}
+
+	t.Run("unknown height", func(t *testing.T) {
+		require.False(t, shouldPage(&Config{Pager: pagerAuto}, long, 0))
+	})
+
+	t.Run("not a terminal", func(t *testing.T) {
+		isOutputTTY = func() bool { return false }
+		t.Cleanup(func() { isOutputTTY = func() bool { return true } })
+		require.False(t, shouldPage(&Config{Pager: pagerAlways}, long, 10))
+	})
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"plugin"
+	"sync"
+)
+
+// The symbols a plugin can export, as functions, to intercept the requests
+// to the APIs and their responses.
+const (
+	requestInterceptorSymbol  = "RequestInterceptor"
+	responseInterceptorSymbol = "ResponseInterceptor"
+)
+
+// requestInterceptor modifies a request before it is sent. Returning an
+// error fails the request.
+type requestInterceptor func(req *http.Request) error
+
+// responseInterceptor modifies a response before it is read. Returning an
+// error fails the request.
+type responseInterceptor func(resp *http.Response) error
+
+// interceptors are the interceptors of a plugin.
+type interceptors struct {
+	path     string
+	request  requestInterceptor
+	response responseInterceptor
+}
+
+var (
+	pluginsMu sync.Mutex
+	// plugins are the plugins loaded so far, by path, nil if they could not
+	// be loaded.
+	plugins = map[string]*interceptors{}
+)
+
+// loadPlugins loads the plugins in the given paths, skipping, with a
+// warning, the ones that can't be loaded.
+func loadPlugins(paths []string) []*interceptors {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	var result []*interceptors
+	for _, path := range paths {
+		i, ok := plugins[path]
+		if !ok {
+			var err error
+			i, err = loadPlugin(path)
+			if err != nil {
+				slog.Warn("disabling plugin", "path", path, "err", err)
+			}
+			plugins[path] = i
+		}
+		if i != nil {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+func loadPlugin(path string) (*interceptors, error) {
+	p, err := plugin.Open(expandHome(path))
+	if err != nil {
+		return nil, fmt.Errorf("could not open plugin: %w", err)
+	}
+	i := &interceptors{path: path}
+	if sym, err := p.Lookup(requestInterceptorSymbol); err == nil {
+		fn, ok := sym.(func(*http.Request) error)
+		if !ok {
+			return nil, fmt.Errorf("%s should be a func(*http.Request) error, got %T", requestInterceptorSymbol, sym)
+		}
+		i.request = fn
+	}
+	if sym, err := p.Lookup(responseInterceptorSymbol); err == nil {
+		fn, ok := sym.(func(*http.Response) error)
+		if !ok {
+			return nil, fmt.Errorf("%s should be a func(*http.Response) error, got %T", responseInterceptorSymbol, sym)
+		}
+		i.response = fn
+	}
+	if i.request == nil && i.response == nil {
+		return nil, fmt.Errorf("plugin exports neither %s nor %s", requestInterceptorSymbol, responseInterceptorSymbol)
+	}
+	return i, nil
+}
+
+// interceptTransport runs the interceptors around the requests of base, in
+// order for the requests, and in reverse order for the responses.
+type interceptTransport struct {
+	base         http.RoundTripper
+	interceptors []*interceptors
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t interceptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the interceptors are allowed to modify the request, but the one given
+	// to RoundTrip must not be.
+	req = req.Clone(req.Context())
+	for _, i := range t.interceptors {
+		if i.request == nil {
+			continue
+		}
+		if err := i.request(req); err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", i.path, err)
+		}
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err //nolint:wrapcheck
+	}
+	for j := len(t.interceptors) - 1; j >= 0; j-- {
+		i := t.interceptors[j]
+		if i.response == nil {
+			continue
+		}
+		if err := i.response(resp); err != nil {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("plugin %s: %w", i.path, err)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPlugins(t *testing.T) {
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	path := filepath.Join(t.TempDir(), "nope.so")
+	require.Empty(t, loadPlugins([]string{path}))
+	require.Empty(t, loadPlugins([]string{path}))
+	require.Equal(t, 1, strings.Count(logs.String(), "disabling plugin"))
+}
+
+func TestInterceptTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, strings.Join(r.Header.Values("X-Order"), ","))
+	}))
+	t.Cleanup(srv.Close)
+
+	var order []string
+	interceptor := func(name string) *interceptors {
+		return &interceptors{
+			path: name,
+			request: func(req *http.Request) error {
+				req.Header.Add("X-Order", name)
+				return nil
+			},
+			response: func(*http.Response) error {
+				order = append(order, name)
+				return nil
+			},
+		}
+	}
+
+	t.Run("order", func(t *testing.T) {
+		order = nil
+		client := &http.Client{Transport: interceptTransport{
+			http.DefaultTransport,
+			[]*interceptors{interceptor("a.so"), {path: "b.so"}, interceptor("c.so")},
+		}}
+		req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close() //nolint:errcheck
+		bts, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "a.so,c.so", string(bts))
+		require.Equal(t, []string{"c.so", "a.so"}, order)
+		require.Empty(t, req.Header.Get("X-Order"))
+	})
+
+	t.Run("request error", func(t *testing.T) {
+		client := &http.Client{Transport: interceptTransport{
+			http.DefaultTransport,
+			[]*interceptors{{
+				path:    "sign.so",
+				request: func(*http.Request) error { return errors.New("no key") },
+			}},
+		}}
+		_, err := client.Get(srv.URL) //nolint:noctx
+		require.ErrorContains(t, err, "plugin sign.so: no key")
+	})
+}
+
+func TestAPIHTTPClientPlugins(t *testing.T) {
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	client, err := apiHTTPClient(&Config{Plugins: []string{filepath.Join(t.TempDir(), "nope.so")}})
+	require.NoError(t, err)
+	require.Nil(t, client)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/mods/internal/cache"
+	"github.com/charmbracelet/mods/internal/proto"
+)
+
+// poolCooldown is how long an endpoint of a pool is skipped after failing.
+const poolCooldown = 5 * time.Minute
+
+// PoolEndpoint is one of the endpoints serving a model pool.
+type PoolEndpoint struct {
+	API   string `yaml:"api"`
+	Model string `yaml:"model"`
+	// Weight is how often the endpoint is picked, relative to the others in
+	// the pool; defaults to 1.
+	Weight int `yaml:"weight"`
+}
+
+func (e PoolEndpoint) String() string { return e.API + "/" + e.Model }
+
+func (e PoolEndpoint) weight() int {
+	if e.Weight <= 0 {
+		return 1
+	}
+	return e.Weight
+}
+
+// healthID is the ID of the endpoint in the health cache.
+func (e PoolEndpoint) healthID() string {
+	sum := sha256.Sum256([]byte(e.String()))
+	return "pool-" + hex.EncodeToString(sum[:8])
+}
+
+// pickEndpoint picks one of the given endpoints by weighted random, using
+// intn to get a random number, skipping the unhealthy ones unless they all
+// are.
+func pickEndpoint(endpoints []PoolEndpoint, healthy func(PoolEndpoint) bool, intn func(int) int) (PoolEndpoint, bool) {
+	var candidates []PoolEndpoint
+	for _, e := range endpoints {
+		if healthy(e) {
+			candidates = append(candidates, e)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = endpoints
+	}
+	var total int
+	for _, e := range candidates {
+		total += e.weight()
+	}
+	if total == 0 {
+		return PoolEndpoint{}, false
+	}
+	n := intn(total)
+	for _, e := range candidates {
+		n -= e.weight()
+		if n < 0 {
+			return e, true
+		}
+	}
+	return candidates[len(candidates)-1], true
+}
+
+// resolvePool resolves one of the endpoints of the given pool, skipping the
+// ones that failed in this run.
+func (m *Mods) resolvePool(cfg *Config, name string, endpoints []PoolEndpoint) (API, Model, error) {
+	var left []PoolEndpoint
+	for _, e := range endpoints {
+		if !m.failedEndpoints[e.String()] {
+			left = append(left, e)
+		}
+	}
+	if len(left) == 0 {
+		left = endpoints
+	}
+	endpoint, ok := pickEndpoint(left, m.endpointHealthy, rand.IntN)
+	if !ok {
+		return API{}, Model{}, modsError{
+			err: newUserErrorf(
+				"Add endpoints to the %s pool in the settings: %s",
+				m.Styles.InlineCode.Render(name),
+				m.Styles.InlineCode.Render("mods --settings"),
+			),
+			reason: fmt.Sprintf("The pool %s has no endpoints.", m.Styles.InlineCode.Render(name)),
+		}
+	}
+	slog.Debug("using pool endpoint", "pool", name, "endpoint", endpoint)
+
+	c := *cfg
+	c.API, c.Model, c.Pools = endpoint.API, endpoint.Model, nil
+	api, mod, err := m.resolveModel(&c)
+	if err != nil {
+		return api, mod, err
+	}
+	mod.pool, mod.endpoint = name, endpoint
+	return api, mod, nil
+}
+
+// endpointHealthy returns whether the endpoint did not fail recently.
+func (m *Mods) endpointHealthy(e PoolEndpoint) bool {
+	health, err := cache.NewExpiring[string](m.Config.CachePath)
+	if err != nil {
+		return true
+	}
+	return health.Read(e.healthID(), func(io.Reader) error { return nil }) != nil
+}
+
+// failover marks the endpoint of the given pooled model as unhealthy, and
+// retries with the other endpoints of its pool, if any is left.
+func (m *Mods) failover(err error, mod Model, content string) (tea.Msg, bool) {
+	if mod.pool == "" || !shouldFailover(err) {
+		return nil, false
+	}
+	endpoint := mod.endpoint
+	if m.failedEndpoints == nil {
+		m.failedEndpoints = map[string]bool{}
+	}
+	m.failedEndpoints[endpoint.String()] = true
+	if health, cerr := cache.NewExpiring[string](m.Config.CachePath); cerr == nil {
+		if werr := health.Write(endpoint.healthID(), time.Now().Add(poolCooldown).Unix(), func(w io.Writer) error {
+			_, err := io.WriteString(w, err.Error())
+			return err //nolint:wrapcheck
+		}); werr != nil {
+			slog.Warn("could not save the endpoint health", "endpoint", endpoint, "err", werr)
+		}
+	}
+
+	for _, e := range m.Config.Pools[mod.pool] {
+		if !m.failedEndpoints[e.String()] {
+			slog.Info("endpoint failed, trying another one", "pool", mod.pool, "endpoint", endpoint, "err", err)
+			return completionInput{content}, true
+		}
+	}
+	return nil, false
+}
+
+// shouldFailover returns whether the error might not happen with another
+// endpoint, unlike bad requests, which are most likely the prompt's fault.
+func shouldFailover(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var perr *proto.ProviderError
+	if errors.As(err, &perr) {
+		return perr.StatusCode != http.StatusBadRequest
+	}
+	return true
+}
+
+// validatePools checks that all the endpoints of the pools are set.
+func validatePools(pools map[string][]PoolEndpoint) error {
+	for name, endpoints := range pools {
+		for i, e := range endpoints {
+			if strings.TrimSpace(e.API) == "" || strings.TrimSpace(e.Model) == "" {
+				return fmt.Errorf("endpoint %d of pool %q needs both an api and a model", i+1, name)
+			}
+			if e.Weight < 0 {
+				return fmt.Errorf("endpoint %s of pool %q has a negative weight", e, name)
+			}
+		}
+	}
+	return nil
+}
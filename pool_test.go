@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/charmbracelet/mods/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickEndpoint(t *testing.T) {
+	east := PoolEndpoint{API: "azure-east", Model: "gpt-4o", Weight: 3}
+	west := PoolEndpoint{API: "azure-west", Model: "gpt-4o"}
+	endpoints := []PoolEndpoint{east, west}
+	allHealthy := func(PoolEndpoint) bool { return true }
+
+	t.Run("weighted", func(t *testing.T) {
+		for n, expect := range []PoolEndpoint{east, east, east, west} {
+			e, ok := pickEndpoint(endpoints, allHealthy, func(total int) int {
+				require.Equal(t, 4, total)
+				return n
+			})
+			require.True(t, ok)
+			require.Equal(t, expect, e)
+		}
+	})
+
+	t.Run("skips unhealthy", func(t *testing.T) {
+		e, ok := pickEndpoint(endpoints, func(e PoolEndpoint) bool { return e != east }, func(total int) int {
+			require.Equal(t, 1, total)
+			return 0
+		})
+		require.True(t, ok)
+		require.Equal(t, west, e)
+	})
+
+	t.Run("all unhealthy", func(t *testing.T) {
+		e, ok := pickEndpoint(endpoints, func(PoolEndpoint) bool { return false }, func(int) int { return 3 })
+		require.True(t, ok)
+		require.Equal(t, west, e)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, ok := pickEndpoint(nil, allHealthy, func(int) int { return 0 })
+		require.False(t, ok)
+	})
+}
+
+func TestPoolFailover(t *testing.T) {
+	newMods := func(t *testing.T) *Mods {
+		t.Helper()
+		return &Mods{
+			Config: &Config{
+				Model:     "gpt-4o",
+				CachePath: t.TempDir(),
+				Pools: map[string][]PoolEndpoint{
+					"gpt-4o": {
+						{API: "azure-east", Model: "4o"},
+						{API: "azure-west", Model: "gpt-4o"},
+					},
+				},
+				APIs: APIs{
+					{Name: "azure-east", Models: map[string]Model{"gpt-4o": {Aliases: []string{"4o"}}}},
+					{Name: "azure-west", Models: map[string]Model{"gpt-4o": {}}},
+				},
+			},
+		}
+	}
+	unavailable := &proto.ProviderError{StatusCode: 503, Message: "overloaded"}
+
+	t.Run("fails over to the other endpoint", func(t *testing.T) {
+		m := newMods(t)
+		_, first, err := m.resolveModel(m.Config)
+		require.NoError(t, err)
+		require.Equal(t, "gpt-4o", first.pool)
+		require.Equal(t, "gpt-4o", first.Name)
+
+		msg, ok := m.failover(unavailable, first, "hi")
+		require.True(t, ok)
+		require.Equal(t, completionInput{"hi"}, msg)
+
+		for range 10 {
+			api, mod, err := m.resolveModel(m.Config)
+			require.NoError(t, err)
+			require.NotEqual(t, first.API, api.Name)
+			require.NotEqual(t, first.endpoint, mod.endpoint)
+		}
+
+		_, second, err := m.resolveModel(m.Config)
+		require.NoError(t, err)
+		_, ok = m.failover(unavailable, second, "hi")
+		require.False(t, ok, "all endpoints failed")
+	})
+
+	t.Run("skips recently failed endpoints in later runs", func(t *testing.T) {
+		m := newMods(t)
+		_, first, err := m.resolveModel(m.Config)
+		require.NoError(t, err)
+		_, ok := m.failover(errors.New("connection refused"), first, "hi")
+		require.True(t, ok)
+
+		next := &Mods{Config: m.Config}
+		for range 10 {
+			_, mod, err := next.resolveModel(next.Config)
+			require.NoError(t, err)
+			require.NotEqual(t, first.endpoint, mod.endpoint)
+		}
+	})
+
+	t.Run("bad requests do not fail over", func(t *testing.T) {
+		m := newMods(t)
+		_, mod, err := m.resolveModel(m.Config)
+		require.NoError(t, err)
+		_, ok := m.failover(&proto.ProviderError{StatusCode: 400}, mod, "hi")
+		require.False(t, ok)
+		require.Empty(t, m.failedEndpoints)
+	})
+
+	t.Run("not pooled", func(t *testing.T) {
+		m := newMods(t)
+		_, ok := m.failover(unavailable, Model{Name: "gpt-4o", API: "azure-west"}, "hi")
+		require.False(t, ok)
+	})
+
+	t.Run("unknown endpoint model", func(t *testing.T) {
+		m := newMods(t)
+		m.Config.Pools["gpt-4o"] = []PoolEndpoint{{API: "azure-east", Model: "nope"}}
+		_, _, err := m.resolveModel(m.Config)
+		require.Error(t, err)
+	})
+}
+
+func TestShouldFailover(t *testing.T) {
+	require.True(t, shouldFailover(errors.New("connection refused")))
+	require.True(t, shouldFailover(&proto.ProviderError{StatusCode: 429}))
+	require.True(t, shouldFailover(fmt.Errorf("wrapped: %w", &proto.ProviderError{StatusCode: 500})))
+	require.False(t, shouldFailover(&proto.ProviderError{StatusCode: 400}))
+	require.False(t, shouldFailover(context.Canceled))
+}
+
+func TestValidatePools(t *testing.T) {
+	require.NoError(t, validatePools(nil))
+	require.NoError(t, validatePools(map[string][]PoolEndpoint{
+		"gpt-4o": {{API: "azure", Model: "gpt-4o", Weight: 2}},
+	}))
+	require.EqualError(t, validatePools(map[string][]PoolEndpoint{
+		"gpt-4o": {{API: "azure"}},
+	}), `endpoint 1 of pool "gpt-4o" needs both an api and a model`)
+	require.EqualError(t, validatePools(map[string][]PoolEndpoint{
+		"gpt-4o": {{API: "azure", Model: "gpt-4o", Weight: -1}},
+	}), `endpoint azure/gpt-4o of pool "gpt-4o" has a negative weight`)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/go-shellwords"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/mods/internal/proto"
+)
+
+const defaultPostProcessTimeout = 10 * time.Second
+
+// postProcessedMsg is a tea.Msg that wraps the post-processed completion.
+type postProcessedMsg struct {
+	content string
+	err     error
+}
+
+func (m *Mods) shouldPostProcess() bool {
+	return m.Config.PostProcessCommand != "" &&
+		!m.postProcessed &&
+		m.Config.Show == "" &&
+		!m.Config.ShowLast
+}
+
+// postProcessCmd pipes the last completion through the configured
+// post-process-command.
+func (m *Mods) postProcessCmd() tea.Msg {
+	var original string
+	if n := len(m.messages); n > 0 && m.messages[n-1].Role == proto.RoleAssistant {
+		original = m.messages[n-1].Content
+	}
+	if original == "" {
+		return postProcessedMsg{}
+	}
+	timeout := m.Config.PostProcessTimeout
+	if timeout <= 0 {
+		timeout = defaultPostProcessTimeout
+	}
+	content, err := postProcess(m.ctx, m.Config.PostProcessCommand, original, timeout)
+	if err != nil {
+		// fail open: keep the original completion.
+		return postProcessedMsg{content: original, err: err}
+	}
+	return postProcessedMsg{content: content}
+}
+
+// applyPostProcessed replaces the completion with the post-processed one,
+// both in the output and in the messages that will be saved.
+func (m *Mods) applyPostProcessed(msg postProcessedMsg) {
+	m.postProcessed = true
+	m.postProcessErr = msg.err
+	output := m.Output
+	if n := len(m.messages); n > 0 && m.messages[n-1].Role == proto.RoleAssistant {
+		output = strings.TrimSuffix(output, m.messages[n-1].Content) + msg.content
+		m.messages[n-1].Content = msg.content
+	}
+	m.Output = ""
+	m.trim = edgeTrimmer{}
+	m.appendToOutput(output)
+}
+
+// postProcess runs the given command with the input as its STDIN, returning
+// its STDOUT.
+func postProcess(ctx context.Context, command, input string, timeout time.Duration) (string, error) {
+	args, err := shellwords.Parse(command)
+	if err != nil {
+		return "", fmt.Errorf("post-process-command: %w", err)
+	}
+	if len(args) == 0 {
+		return "", errors.New("post-process-command: empty command")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("post-process-command: timed out after %s", timeout)
+		}
+		return "", fmt.Errorf("post-process-command: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/mods/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix commands")
+	}
+
+	t.Run("replaces", func(t *testing.T) {
+		out, err := postProcess(context.Background(), "tr a-z A-Z", "hello", time.Second)
+		require.NoError(t, err)
+		require.Equal(t, "HELLO", out)
+	})
+
+	t.Run("fails", func(t *testing.T) {
+		_, err := postProcess(context.Background(), "false", "hello", time.Second)
+		require.Error(t, err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		_, err := postProcess(context.Background(), "sleep 5", "hello", 50*time.Millisecond)
+		require.EqualError(t, err, "post-process-command: timed out after 50ms")
+	})
+
+	t.Run("fails open", func(t *testing.T) {
+		m := &Mods{
+			ctx: context.Background(),
+			Config: &Config{
+				Raw:                true,
+				PostProcessCommand: "false",
+			},
+			messages: []proto.Message{
+				{Role: proto.RoleUser, Content: "hi"},
+				{Role: proto.RoleAssistant, Content: "hello"},
+			},
+		}
+		msg := m.postProcessCmd().(postProcessedMsg)
+		require.Error(t, msg.err)
+		require.Equal(t, "hello", msg.content)
+	})
+
+	t.Run("applies", func(t *testing.T) {
+		m := &Mods{
+			ctx:          context.Background(),
+			contentMutex: &sync.Mutex{},
+			Output:       "prompt\n\nhello",
+			Config: &Config{
+				Raw:                true,
+				PostProcessCommand: "tr a-z A-Z",
+			},
+			messages: []proto.Message{
+				{Role: proto.RoleUser, Content: "hi"},
+				{Role: proto.RoleAssistant, Content: "hello"},
+			},
+		}
+		m.applyPostProcessed(m.postProcessCmd().(postProcessedMsg))
+		require.Equal(t, "prompt\n\nHELLO", m.Output)
+		require.Equal(t, "prompt\n\nHELLO", strings.Join(m.content, "")+m.lineBuf.flush())
+		require.Equal(t, "HELLO", m.messages[1].Content)
+	})
+}
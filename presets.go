@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Preset is a named set of sampling parameters, picked with --preset.
+//
+// Unset parameters are left as they are.
+type Preset struct {
+	Temperature *float64 `yaml:"temp,omitempty"`
+	TopP        *float64 `yaml:"topp,omitempty"`
+	TopK        *int64   `yaml:"topk,omitempty"`
+
+	FrequencyPenalty *float64 `yaml:"frequency-penalty,omitempty"`
+	PresencePenalty  *float64 `yaml:"presence-penalty,omitempty"`
+}
+
+// defaultPresets are the presets available without defining them in the
+// settings.
+func defaultPresets() map[string]Preset {
+	return map[string]Preset{
+		"creative": {Temperature: ptr(1.2), TopP: ptr(0.95), FrequencyPenalty: ptr(0.5), PresencePenalty: ptr(0.6)},
+		"balanced": {Temperature: ptr(0.7), TopP: ptr(0.9), FrequencyPenalty: ptr(0.2), PresencePenalty: ptr(0.2)},
+		"precise":  {Temperature: ptr(0.2), TopP: ptr(0.5), FrequencyPenalty: ptr(0.0), PresencePenalty: ptr(0.0)},
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// validatePresets checks that the parameters of the presets are in range.
+func validatePresets(presets map[string]Preset) error {
+	for _, name := range slices.Sorted(maps.Keys(presets)) {
+		p := presets[name]
+		if p.Temperature != nil && *p.Temperature != -1 && (*p.Temperature < 0 || *p.Temperature > 2) {
+			return fmt.Errorf("preset %q: temp must be from 0.0 to 2.0, or -1.0 to disable it", name)
+		}
+		if p.TopP != nil && *p.TopP != -1 && (*p.TopP < 0 || *p.TopP > 1) {
+			return fmt.Errorf("preset %q: topp must be from 0.0 to 1.0, or -1.0 to disable it", name)
+		}
+		if p.TopK != nil && *p.TopK < -1 {
+			return fmt.Errorf("preset %q: topk must be positive, or -1 to disable it", name)
+		}
+		if err := validatePenalties(p.FrequencyPenalty, p.PresencePenalty); err != nil {
+			return fmt.Errorf("preset %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// applyPreset sets the sampling parameters of the chosen preset, if any,
+// except the ones set with flags.
+func applyPreset(cfg *Config, flagSet func(name string) bool) error {
+	if cfg.Preset == "" {
+		return nil
+	}
+	p, ok := cfg.Presets[cfg.Preset]
+	if !ok {
+		return modsError{
+			err: newUserErrorf(
+				"Valid presets are: %s",
+				strings.Join(slices.Sorted(maps.Keys(cfg.Presets)), ", "),
+			),
+			reason: fmt.Sprintf("Unknown preset %q.", cfg.Preset),
+		}
+	}
+	if p.Temperature != nil && !flagSet("temp") {
+		cfg.Temperature = *p.Temperature
+	}
+	if p.TopP != nil && !flagSet("topp") {
+		cfg.TopP = *p.TopP
+	}
+	if p.TopK != nil && !flagSet("topk") {
+		cfg.TopK = *p.TopK
+	}
+	if p.FrequencyPenalty != nil && !flagSet("frequency-penalty") {
+		cfg.FrequencyPenalty = p.FrequencyPenalty
+	}
+	if p.PresencePenalty != nil && !flagSet("presence-penalty") {
+		cfg.PresencePenalty = p.PresencePenalty
+	}
+	slog.Debug(
+		"using preset",
+		"preset", cfg.Preset,
+		"temp", cfg.Temperature,
+		"topp", cfg.TopP,
+		"topk", cfg.TopK,
+		"frequency-penalty", penaltyValue(cfg.FrequencyPenalty),
+		"presence-penalty", penaltyValue(cfg.PresencePenalty),
+	)
+	return nil
+}
+
+// penaltyValue returns the penalty to be logged, or nil if it's not set.
+func penaltyValue(penalty *float64) any {
+	if penalty == nil {
+		return nil
+	}
+	return *penalty
+}
+
+const maxPenalty = 2.0
+
+// validatePenalty checks that the penalty, if set, is from -2.0 to 2.0.
+func validatePenalty(penalty *float64) error {
+	if penalty != nil && (*penalty < -maxPenalty || *penalty > maxPenalty) {
+		return fmt.Errorf("penalties must be from -2.0 to 2.0, got %v", *penalty)
+	}
+	return nil
+}
+
+// validatePenalties checks the frequency and presence penalties.
+func validatePenalties(frequency, presence *float64) error {
+	if err := validatePenalty(frequency); err != nil {
+		return fmt.Errorf("frequency-penalty: %w", err)
+	}
+	if err := validatePenalty(presence); err != nil {
+		return fmt.Errorf("presence-penalty: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePresets(t *testing.T) {
+	require.NoError(t, validatePresets(defaultPresets()))
+	require.NoError(t, validatePresets(map[string]Preset{"off": {Temperature: ptr(-1.0), TopP: ptr(-1.0), TopK: ptr[int64](-1)}}))
+
+	for name, preset := range map[string]Preset{
+		"temp too high": {Temperature: ptr(2.5)},
+		"negative temp": {Temperature: ptr(-0.5)},
+		"topp too high": {TopP: ptr(1.5)},
+		"negative topk": {TopK: ptr[int64](-2)},
+		"penalty":       {FrequencyPenalty: ptr(2.5)},
+		"presence":      {PresencePenalty: ptr(-2.5)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Error(t, validatePresets(map[string]Preset{name: preset}))
+		})
+	}
+}
+
+func TestApplyPreset(t *testing.T) {
+	noFlags := func(string) bool { return false }
+
+	t.Run("none", func(t *testing.T) {
+		cfg := Config{Temperature: 1, TopP: 1, Presets: defaultPresets()}
+		require.NoError(t, applyPreset(&cfg, noFlags))
+		require.Equal(t, 1.0, cfg.Temperature)
+		require.Equal(t, 1.0, cfg.TopP)
+	})
+
+	t.Run("preset", func(t *testing.T) {
+		cfg := Config{Temperature: 1, TopP: 1, TopK: 50, Preset: "precise", Presets: defaultPresets()}
+		require.NoError(t, applyPreset(&cfg, noFlags))
+		require.Equal(t, 0.2, cfg.Temperature)
+		require.Equal(t, 0.5, cfg.TopP)
+		require.Equal(t, int64(50), cfg.TopK)
+	})
+
+	t.Run("flags override", func(t *testing.T) {
+		cfg := Config{Temperature: 0.9, TopP: 1, PresencePenalty: ptr(1.5), Preset: "creative", Presets: defaultPresets()}
+		require.NoError(t, applyPreset(&cfg, func(name string) bool { return name == "temp" || name == "presence-penalty" }))
+		require.Equal(t, 0.9, cfg.Temperature)
+		require.Equal(t, 0.95, cfg.TopP)
+		require.Equal(t, 0.5, *cfg.FrequencyPenalty)
+		require.Equal(t, 1.5, *cfg.PresencePenalty)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		cfg := Config{Preset: "wild", Presets: defaultPresets()}
+		err := applyPreset(&cfg, noFlags)
+		require.Error(t, err)
+		require.Equal(t, `Unknown preset "wild".`, err.(modsError).reason)
+	})
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1" //nolint: gosec
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/mods/internal/cache"
+)
+
+const (
+	// remoteTTL is how long a fetched prompt is used before fetching it again.
+	remoteTTL = 5 * time.Minute
+	// remoteKeep is how long a fetched prompt is kept around to be used if
+	// fetching it again fails.
+	remoteKeep = 7 * 24 * time.Hour
+	// remoteMaxSize is the maximum size of a fetched prompt.
+	remoteMaxSize = 1 << 20
+)
+
+type remoteContent struct {
+	Content   string `json:"content"`
+	FetchedAt int64  `json:"fetched_at"`
+}
+
+// loadRemote fetches the prompt or role at the given URL.
+//
+// Fetched contents are cached for a short while; if fetching fails, the
+// cached content is used instead, even if older than that.
+func loadRemote(ctx context.Context, cfg *Config, url string) (string, error) {
+	if !cfg.AllowRemote {
+		return "", modsError{
+			err: newUserErrorf(
+				"Use %s to allow fetching prompts and roles over the network.",
+				stderrStyles().InlineCode.Render("--allow-remote"),
+			),
+			reason: fmt.Sprintf("Fetching %s is not allowed.", url),
+		}
+	}
+
+	id := fmt.Sprintf("remote-%x", sha1.Sum([]byte(url))) //nolint: gosec
+	remotes, err := cache.NewExpiring[remoteContent](cfg.CachePath)
+	if err != nil {
+		slog.Warn("could not open the remote prompts cache", "err", err)
+	}
+
+	var cached remoteContent
+	if remotes != nil {
+		_ = remotes.Read(id, func(r io.Reader) error {
+			return json.NewDecoder(r).Decode(&cached) //nolint:wrapcheck
+		})
+	}
+	if cached.Content != "" && time.Since(time.Unix(cached.FetchedAt, 0)) < remoteTTL {
+		return cached.Content, nil
+	}
+
+	client, err := apiHTTPClient(cfg)
+	if err != nil {
+		return "", modsError{err, "There was an error parsing your proxy URL."}
+	}
+	content, err := fetchRemote(ctx, client, url)
+	if err != nil {
+		if cached.Content != "" {
+			slog.Warn("could not fetch, using the cached content", "url", url, "err", err)
+			return cached.Content, nil
+		}
+		return "", modsError{err, fmt.Sprintf("Could not fetch %s.", url)}
+	}
+
+	if remotes != nil {
+		fetched := remoteContent{Content: content, FetchedAt: time.Now().Unix()}
+		if err := remotes.Write(id, time.Now().Add(remoteKeep).Unix(), func(w io.Writer) error {
+			return json.NewEncoder(w).Encode(fetched) //nolint:wrapcheck
+		}); err != nil {
+			slog.Warn("could not cache the fetched content", "url", url, "err", err)
+		}
+	}
+	return content, nil
+}
+
+func fetchRemote(ctx context.Context, client *http.Client, url string) (string, error) {
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return "", fmt.Errorf("%s is not an http(s) URL", url)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("could not create request: %w", err)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := debugHTTPClient(ctx, client).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("could not fetch: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	if err := checkContentType(resp.Header.Get("Content-Type")); err != nil {
+		return "", err
+	}
+
+	bts, err := io.ReadAll(io.LimitReader(resp.Body, remoteMaxSize+1))
+	if err != nil {
+		return "", fmt.Errorf("could not read response: %w", err)
+	}
+	if len(bts) > remoteMaxSize {
+		return "", fmt.Errorf("content is larger than %d bytes", remoteMaxSize)
+	}
+	return string(bts), nil
+}
+
+// checkContentType errors if the content type is not text.
+func checkContentType(contentType string) error {
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid content type: %w", err)
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json",
+		mediaType == "application/yaml",
+		mediaType == "application/x-yaml":
+		return nil
+	default:
+		return errors.New("unsupported content type: " + mediaType)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1" //nolint: gosec
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/mods/internal/cache"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRemote(t *testing.T) {
+	var fail atomic.Bool
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		switch r.URL.Path {
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("nope"))
+		case "/large":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte(strings.Repeat("a", remoteMaxSize+1)))
+		default:
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			_, _ = w.Write([]byte("you are a reviewer"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+
+	t.Run("not allowed", func(t *testing.T) {
+		_, err := loadRemote(ctx, &Config{CachePath: t.TempDir()}, srv.URL)
+		require.Error(t, err)
+		require.Zero(t, hits.Load())
+	})
+
+	t.Run("fetch and cache", func(t *testing.T) {
+		hits.Store(0)
+		cfg := &Config{CachePath: t.TempDir(), AllowRemote: true}
+		content, err := loadRemote(ctx, cfg, srv.URL+"/role")
+		require.NoError(t, err)
+		require.Equal(t, "you are a reviewer", content)
+
+		content, err = loadRemote(ctx, cfg, srv.URL+"/role")
+		require.NoError(t, err)
+		require.Equal(t, "you are a reviewer", content)
+		require.Equal(t, int32(1), hits.Load())
+	})
+
+	t.Run("falls back to cache", func(t *testing.T) {
+		cfg := &Config{CachePath: t.TempDir(), AllowRemote: true}
+		url := srv.URL + "/role"
+		remotes, err := cache.NewExpiring[remoteContent](cfg.CachePath)
+		require.NoError(t, err)
+		require.NoError(t, remotes.Write(
+			fmt.Sprintf("remote-%x", sha1.Sum([]byte(url))), //nolint: gosec
+			time.Now().Add(time.Hour).Unix(),
+			func(w io.Writer) error {
+				return json.NewEncoder(w).Encode(remoteContent{
+					Content:   "stale role",
+					FetchedAt: time.Now().Add(-time.Hour).Unix(),
+				})
+			},
+		))
+
+		fail.Store(true)
+		t.Cleanup(func() { fail.Store(false) })
+
+		content, err := loadRemote(ctx, cfg, url)
+		require.NoError(t, err)
+		require.Equal(t, "stale role", content)
+
+		_, err = loadRemote(ctx, &Config{CachePath: t.TempDir(), AllowRemote: true}, url)
+		require.Error(t, err)
+	})
+
+	t.Run("content type", func(t *testing.T) {
+		_, err := loadRemote(ctx, &Config{CachePath: t.TempDir(), AllowRemote: true}, srv.URL+"/image")
+		require.Error(t, err)
+		require.ErrorContains(t, err.(modsError).err, "unsupported content type: image/png")
+	})
+
+	t.Run("size", func(t *testing.T) {
+		_, err := loadRemote(ctx, &Config{CachePath: t.TempDir(), AllowRemote: true}, srv.URL+"/large")
+		require.Error(t, err)
+	})
+
+	t.Run("through the proxy", func(t *testing.T) {
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Host != "prompts.invalid" {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("you are a proxied reviewer"))
+		}))
+		t.Cleanup(proxy.Close)
+
+		cfg := &Config{CachePath: t.TempDir(), AllowRemote: true, HTTPProxy: proxy.URL}
+		content, err := loadRemote(ctx, cfg, "http://prompts.invalid/role")
+		require.NoError(t, err)
+		require.Equal(t, "you are a proxied reviewer", content)
+
+		_, err = loadRemote(ctx, &Config{CachePath: t.TempDir(), AllowRemote: true, HTTPProxy: "://nope"}, srv.URL)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/charmbracelet/mods/internal/cache"
+	"github.com/charmbracelet/mods/internal/proto"
+)
+
+// replayTurn is a user turn of a saved conversation, and the answer it got.
+type replayTurn struct {
+	// history is everything that came before the turn.
+	history []proto.Message
+	input   string
+	answer  string
+}
+
+// replayTurns splits the given conversation into its user turns.
+//
+// The answer of a turn is everything the assistant said until the next user
+// message, so answers with tool calls are compared as a whole.
+func replayTurns(messages []proto.Message) []replayTurn {
+	var turns []replayTurn
+	for i, msg := range messages {
+		switch msg.Role {
+		case proto.RoleUser:
+			turns = append(turns, replayTurn{
+				history: slices.Clone(messages[:i:i]),
+				input:   msg.Content,
+			})
+		case proto.RoleAssistant:
+			if len(turns) == 0 || msg.Content == "" {
+				continue
+			}
+			turn := &turns[len(turns)-1]
+			turn.answer = strings.TrimSpace(turn.answer + "\n\n" + msg.Content)
+		}
+	}
+	return turns
+}
+
+// replayer sends the input after the given history, returning the full
+// response.
+type replayer func(ctx context.Context, history []proto.Message, input string) (string, error)
+
+// runReverify sends every turn again, one at a time, and checks the new
+// answers against the saved ones.
+//
+// Each turn is sent after the saved history, not the new answers, so a turn
+// that diverges does not make the following ones diverge as well.
+func runReverify(ctx context.Context, turns []replayTurn, replay replayer) []evalResult {
+	results := make([]evalResult, 0, len(turns))
+	for i, turn := range turns {
+		result := evalResult{Name: fmt.Sprintf("turn %d: %s", i+1, turnSummary(turn.input))}
+		if err := ctx.Err(); err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+		output, err := replay(ctx, turn.history, turn.input)
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+		output = strings.TrimSpace(output)
+		result.Pass = output == turn.answer
+		if !result.Pass {
+			result.Reason = "answer diverges from the saved one"
+			result.Details = udiff.Unified("saved", "replayed", turn.answer+"\n", output+"\n")
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+const turnSummaryLen = 40
+
+// turnSummary returns the first line of the input, shortened.
+func turnSummary(input string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(input), "\n")
+	if r := []rune(line); len(r) > turnSummaryLen {
+		return string(r[:turnSummaryLen]) + "…"
+	}
+	return line
+}
+
+// reverifyCompleter returns a [replayer] that uses the given config, with the
+// api and model the conversation was saved with, if any.
+func reverifyCompleter(cfg *Config, convo *Conversation) replayer {
+	return func(ctx context.Context, history []proto.Message, input string) (string, error) {
+		c := *cfg
+		if convo.API != nil && convo.Model != nil {
+			c.API, c.Model = *convo.API, *convo.Model
+		}
+		c.Prefix, c.PromptURL, c.ShellHistory = "", "", 0
+		c.Show, c.ShowLast, c.Reverify = "", false, ""
+		c.cacheReadFromID, c.cacheWriteToID = "", ""
+		m := newMods(ctx, stdoutRenderer(), &c, nil, nil)
+		m.history = history
+		return m.complete(input)
+	}
+}
+
+// reverifyConversation sends the user turns of the given conversation again,
+// and prints where the new answers diverge from the saved ones.
+func reverifyConversation(ctx context.Context, in string) error {
+	convo, err := db.Find(in)
+	if err != nil {
+		return modsError{err, "Couldn't find conversation."}
+	}
+	convos, err := cache.NewConversations(config.CachePath)
+	if err != nil {
+		return modsError{err, "There was an error loading the conversation."}
+	}
+	var messages []proto.Message
+	if err := convos.Read(convo.ID, &messages); err != nil {
+		return modsError{err, "There was an error loading the conversation."}
+	}
+	turns := replayTurns(messages)
+	if len(turns) == 0 {
+		return modsError{
+			err:    fmt.Errorf("conversation %s has no user turns", convo.ID[:sha1short]),
+			reason: "Nothing to reverify.",
+		}
+	}
+
+	results := runReverify(ctx, turns, reverifyCompleter(&config, convo))
+	if failed := printEvalResults(results); failed > 0 {
+		return modsError{
+			err:    fmt.Errorf("%d of %d turns did not reproduce", failed, len(results)),
+			reason: "Reverify failed.",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/mods/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayTurns(t *testing.T) {
+	messages := []proto.Message{
+		{Role: proto.RoleSystem, Content: "be brief"},
+		{Role: proto.RoleUser, Content: "first 2 primes"},
+		{Role: proto.RoleAssistant, Content: "2 and 3"},
+		{Role: proto.RoleUser, Content: "weather in lisbon?"},
+		{Role: proto.RoleAssistant, ToolCalls: []proto.ToolCall{{ID: "call_1"}}},
+		{Role: proto.RoleTool, Content: "21C"},
+		{Role: proto.RoleAssistant, Content: "It's warm."},
+	}
+	turns := replayTurns(messages)
+	require.Len(t, turns, 2)
+
+	require.Equal(t, "first 2 primes", turns[0].input)
+	require.Equal(t, "2 and 3", turns[0].answer)
+	require.Equal(t, messages[:1], turns[0].history)
+
+	require.Equal(t, "weather in lisbon?", turns[1].input)
+	require.Equal(t, "It's warm.", turns[1].answer)
+	require.Equal(t, messages[:3], turns[1].history)
+
+	t.Run("history is never nil", func(t *testing.T) {
+		turns := replayTurns(messages[1:])
+		require.NotNil(t, turns[0].history)
+		require.Empty(t, turns[0].history)
+	})
+}
+
+func TestRunReverify(t *testing.T) {
+	turns := []replayTurn{
+		{input: "first 2 primes", answer: "2 and 3"},
+		{input: "say hi\nplease", answer: "hi"},
+		{input: "fail"},
+	}
+	results := runReverify(context.Background(), turns, func(_ context.Context, _ []proto.Message, input string) (string, error) {
+		switch input {
+		case "first 2 primes":
+			return "2 and 3\n", nil
+		case "fail":
+			return "", errors.New("boom")
+		default:
+			return "hello", nil
+		}
+	})
+	require.Len(t, results, 3)
+
+	require.True(t, results[0].Pass)
+	require.Equal(t, "turn 1: first 2 primes", results[0].Name)
+
+	require.False(t, results[1].Pass)
+	require.Equal(t, "turn 2: say hi", results[1].Name)
+	require.Contains(t, results[1].Details, "-hi")
+	require.Contains(t, results[1].Details, "+hello")
+
+	require.EqualError(t, results[2].Err, "boom")
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		results := runReverify(ctx, turns, func(context.Context, []proto.Message, string) (string, error) {
+			t.Fatal("should not replay")
+			return "", nil
+		})
+		for _, r := range results {
+			require.ErrorIs(t, r.Err, context.Canceled)
+		}
+	})
+}
+
+func TestTurnSummary(t *testing.T) {
+	require.Equal(t, "hi", turnSummary("  hi\nthere"))
+	require.Equal(t, strings.Repeat("á", turnSummaryLen)+"…", turnSummary(strings.Repeat("á", 50)))
+}
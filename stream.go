@@ -2,13 +2,48 @@ package main
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/mods/internal/proto"
 )
 
+// errNothingToSend happens when there's no user content to send, even if
+// there are system messages (e.g. from a role).
+var errNothingToSend = modsError{
+	err: newUserErrorf(
+		"You can give your prompt as arguments and/or pipe it from STDIN.\nExample: %s",
+		stdoutStyles().InlineCode.Render("mods [prompt]"),
+	),
+	reason: "Nothing to send.",
+}
+
+// roleNone is the role name used to explicitly disable any role, including
+// the one set in the settings file.
+const roleNone = "none"
+
+// resolveRole returns the role that should be used, if any.
+//
+// --no-role and --role=none both mean no role at all, regardless of the
+// configured default role. Otherwise, the role given with --role is used,
+// falling back to the default role from the settings.
+func resolveRole(cfg *Config) string {
+	if cfg.NoRole || cfg.Role == roleNone {
+		return ""
+	}
+	return cfg.Role
+}
+
 func (m *Mods) setupStreamContext(content string, mod Model) error {
 	cfg := m.Config
+	if m.history != nil {
+		m.messages = append(slices.Clone(m.history), proto.Message{
+			Role:    proto.RoleUser,
+			Content: content,
+		})
+		return nil
+	}
+
 	m.messages = []proto.Message{}
 	if txt := cfg.FormatText[cfg.FormatAs]; cfg.Format && txt != "" {
 		m.messages = append(m.messages, proto.Message{
@@ -17,11 +52,20 @@ func (m *Mods) setupStreamContext(content string, mod Model) error {
 		})
 	}
 
-	if cfg.Role != "" {
-		roleSetup, ok := cfg.Roles[cfg.Role]
+	if cfg.RoleURL != "" {
+		role, err := loadRemote(m.ctx, cfg, cfg.RoleURL)
+		if err != nil {
+			return err
+		}
+		m.messages = append(m.messages, proto.Message{
+			Role:    proto.RoleSystem,
+			Content: role,
+		})
+	} else if role := resolveRole(cfg); role != "" {
+		roleSetup, ok := cfg.Roles[role]
 		if !ok {
 			return modsError{
-				err:    fmt.Errorf("role %q does not exist", cfg.Role),
+				err:    fmt.Errorf("role %q does not exist", role),
 				reason: "Could not use role",
 			}
 		}
@@ -40,10 +84,60 @@ func (m *Mods) setupStreamContext(content string, mod Model) error {
 		}
 	}
 
-	if prefix := cfg.Prefix; prefix != "" {
+	if !cfg.NoContextFiles {
+		maxChars := mod.MaxChars
+		if cfg.NoLimit {
+			maxChars = 0
+		}
+		if txt := contextFilesMessage(cfg.ContextFiles, maxChars); txt != "" {
+			m.messages = append(m.messages, proto.Message{
+				Role:    proto.RoleSystem,
+				Content: txt,
+			})
+		}
+	}
+
+	if txt := agentInstruction(cfg); txt != "" {
+		m.messages = append(m.messages, proto.Message{
+			Role:    proto.RoleSystem,
+			Content: txt,
+		})
+	}
+
+	if txt := languageInstruction(cfg); txt != "" {
+		m.messages = append(m.messages, proto.Message{
+			Role:    proto.RoleSystem,
+			Content: txt,
+		})
+	}
+
+	prefix := cfg.Prefix
+	if cfg.PromptURL != "" {
+		prompt, err := loadRemote(m.ctx, cfg, cfg.PromptURL)
+		if err != nil {
+			return err
+		}
+		prefix = strings.TrimSpace(prompt + "\n\n" + prefix)
+	}
+
+	if prefix != "" {
 		content = strings.TrimSpace(prefix + "\n\n" + content)
 	}
 
+	if strings.TrimSpace(content) == "" {
+		return errNothingToSend
+	}
+
+	if cfg.ShellHistory > 0 {
+		cmds, err := shellHistory(cfg.ShellHistory)
+		if err != nil {
+			return modsError{err, "Could not include the shell history."}
+		}
+		if len(cmds) > 0 {
+			content = strings.TrimSpace(content + "\n\nMy recent shell commands, oldest first:\n\n" + strings.Join(cmds, "\n"))
+		}
+	}
+
 	if !cfg.NoLimit && int64(len(content)) > mod.MaxChars {
 		content = content[:mod.MaxChars]
 	}
@@ -61,6 +155,36 @@ func (m *Mods) setupStreamContext(content string, mod Model) error {
 		}
 	}
 
+	if cfg.ContinueFile != "" {
+		messages, err := readConversationFile(cfg.ContinueFile)
+		if err != nil {
+			return modsError{err, "There was a problem reading the conversation file."}
+		}
+		if len(messages) > 0 {
+			m.messages = messages
+		}
+	}
+
+	// the attached directories are sent with the prompt, rather than with
+	// the other context, so they are also sent when continuing, and get
+	// what's left of max-input-chars.
+	if len(cfg.AttachDirs) > 0 {
+		used := int64(len(content))
+		for _, msg := range m.messages {
+			used += int64(len(msg.Content))
+		}
+		txt, err := m.attachDirs(used, mod)
+		if err != nil {
+			return err
+		}
+		if txt != "" {
+			m.messages = append(m.messages, proto.Message{
+				Role:    proto.RoleSystem,
+				Content: txt,
+			})
+		}
+	}
+
 	m.messages = append(m.messages, proto.Message{
 		Role:    proto.RoleUser,
 		Content: content,
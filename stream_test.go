@@ -0,0 +1,196 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/mods/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupStreamContextRoles(t *testing.T) {
+	newMods := func(role string, noRole bool) *Mods {
+		return &Mods{
+			Config: &Config{
+				Role:    role,
+				NoRole:  noRole,
+				NoLimit: true,
+				Roles: map[string][]string{
+					"default": {"you are the default role"},
+					"shell":   {"you are a shell expert"},
+				},
+			},
+		}
+	}
+
+	systemMessages := func(m *Mods) []string {
+		var result []string
+		for _, msg := range m.messages {
+			if msg.Role == proto.RoleSystem {
+				result = append(result, msg.Content)
+			}
+		}
+		return result
+	}
+
+	t.Run("default role", func(t *testing.T) {
+		m := newMods("default", false)
+		require.NoError(t, m.setupStreamContext("hi", Model{}))
+		require.Equal(t, []string{"you are the default role"}, systemMessages(m))
+	})
+
+	t.Run("given role", func(t *testing.T) {
+		m := newMods("shell", false)
+		require.NoError(t, m.setupStreamContext("hi", Model{}))
+		require.Equal(t, []string{"you are a shell expert"}, systemMessages(m))
+	})
+
+	t.Run("role none", func(t *testing.T) {
+		m := newMods(roleNone, false)
+		require.NoError(t, m.setupStreamContext("hi", Model{}))
+		require.Empty(t, systemMessages(m))
+	})
+
+	t.Run("no role", func(t *testing.T) {
+		m := newMods("default", true)
+		require.NoError(t, m.setupStreamContext("hi", Model{}))
+		require.Empty(t, systemMessages(m))
+	})
+
+	t.Run("missing role", func(t *testing.T) {
+		m := newMods("nope", false)
+		require.EqualError(t, m.setupStreamContext("hi", Model{}), `role "nope" does not exist`)
+	})
+}
+
+func TestSetupStreamContextNothingToSend(t *testing.T) {
+	newMods := func() *Mods {
+		return &Mods{
+			Config: &Config{
+				Role:    "shell",
+				NoLimit: true,
+				Roles: map[string][]string{
+					"shell": {"you are a shell expert"},
+				},
+			},
+		}
+	}
+
+	for name, content := range map[string]string{
+		"empty":      "",
+		"whitespace": " \n\t ",
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := newMods()
+			err := m.setupStreamContext(content, Model{})
+			require.ErrorIs(t, err, errNothingToSend)
+			require.Equal(t, "Nothing to send.", err.(modsError).reason)
+		})
+	}
+
+	t.Run("whitespace prefix", func(t *testing.T) {
+		m := newMods()
+		m.Config.Prefix = "  "
+		require.ErrorIs(t, m.setupStreamContext("", Model{}), errNothingToSend)
+	})
+
+	t.Run("prefix only", func(t *testing.T) {
+		m := newMods()
+		m.Config.Prefix = "hi"
+		require.NoError(t, m.setupStreamContext("", Model{}))
+		require.Equal(t, "hi", m.messages[len(m.messages)-1].Content)
+	})
+}
+
+func TestSetupStreamContextContextFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "standards.md")
+	require.NoError(t, os.WriteFile(path, []byte("Use tabs."), 0o600))
+
+	t.Run("included", func(t *testing.T) {
+		m := &Mods{Config: &Config{NoLimit: true, ContextFiles: []string{path}}}
+		require.NoError(t, m.setupStreamContext("hi", Model{}))
+		require.Len(t, m.messages, 2)
+		require.Equal(t, proto.RoleSystem, m.messages[0].Role)
+		require.Contains(t, m.messages[0].Content, "Use tabs.")
+	})
+
+	t.Run("no context files", func(t *testing.T) {
+		m := &Mods{Config: &Config{NoLimit: true, ContextFiles: []string{path}, NoContextFiles: true}}
+		require.NoError(t, m.setupStreamContext("hi", Model{}))
+		require.Len(t, m.messages, 1)
+	})
+}
+
+func TestSetupStreamContextContinueFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.json")
+
+	t.Run("missing file", func(t *testing.T) {
+		m := &Mods{Config: &Config{
+			NoLimit:      true,
+			ContinueFile: path,
+			Role:         "shell",
+			Roles:        map[string][]string{"shell": {"you are a shell expert"}},
+		}}
+		require.NoError(t, m.setupStreamContext("hi", Model{}))
+		require.Len(t, m.messages, 2)
+		require.Equal(t, proto.RoleSystem, m.messages[0].Role)
+	})
+
+	t.Run("continues", func(t *testing.T) {
+		require.NoError(t, writeConversationFile(path, []proto.Message{
+			{Role: proto.RoleUser, Content: "first 2 primes"},
+			{Role: proto.RoleAssistant, Content: "2 and 3"},
+		}))
+		m := &Mods{Config: &Config{NoLimit: true, ContinueFile: path}}
+		require.NoError(t, m.setupStreamContext("and the next one?", Model{}))
+		require.Len(t, m.messages, 3)
+		require.Equal(t, "2 and 3", m.messages[1].Content)
+		require.Equal(t, "and the next one?", m.messages[2].Content)
+	})
+}
+
+func TestSetupStreamContextAttachment(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o600))
+	section := int64(len(attachHeader + fileSection(filepath.Join(dir, "main.go"), "package main\n")))
+
+	t.Run("continues", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "chat.json")
+		require.NoError(t, writeConversationFile(path, []proto.Message{
+			{Role: proto.RoleUser, Content: "what does main do?"},
+			{Role: proto.RoleAssistant, Content: "nothing"},
+		}))
+		m := &Mods{Config: &Config{NoLimit: true, ContinueFile: path, AttachDirs: []string{dir}}}
+		require.NoError(t, m.setupStreamContext("and now?", Model{}))
+		require.Len(t, m.messages, 4)
+		require.Equal(t, proto.RoleSystem, m.messages[2].Role)
+		require.Contains(t, m.messages[2].Content, "package main")
+		require.Equal(t, "and now?", m.messages[3].Content)
+	})
+
+	t.Run("fits in what's left of the model's max chars", func(t *testing.T) {
+		prompt := "what does main do?"
+		m := &Mods{Config: &Config{AttachDirs: []string{dir}}}
+		require.NoError(t, m.setupStreamContext(prompt, Model{MaxChars: section + int64(len(prompt))}))
+		require.Len(t, m.messages, 2)
+		require.Contains(t, m.messages[0].Content, "package main")
+		require.Equal(t, int64(len(prompt))+section, m.attachMaxChars)
+	})
+
+	t.Run("the prompt leaves no room", func(t *testing.T) {
+		prompt := "what does main do, really?"
+		m := &Mods{Config: &Config{
+			AttachDirs: []string{dir},
+			Role:       "go",
+			Roles:      map[string][]string{"go": {"you are a go expert"}},
+		}}
+		require.NoError(t, m.setupStreamContext(prompt, Model{MaxChars: section + int64(len(prompt))}))
+		require.Len(t, m.messages, 2, "only the role and the prompt should be sent")
+		require.Equal(t, []attachedFile{{
+			path:    filepath.Join(dir, "main.go"),
+			chars:   section - int64(len(attachHeader)),
+			skipped: attachSkipOverCap,
+		}}, m.attached)
+	})
+}
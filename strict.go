@@ -0,0 +1,94 @@
+package main
+
+import (
+	"log/slog"
+	"regexp"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/mods/internal/proto"
+)
+
+const defaultStrictToolsRetries = 2
+
+// strictToolsPrompt is sent, with --strict-tools, when the model answers with
+// tool results it made up.
+const strictToolsPrompt = "Your last answer contains tool results, but you did not call any tool, " +
+	"so they are made up. Do not write tool results yourself: call the tools you need, " +
+	"and answer using their actual results."
+
+// fakeToolResults matches what models usually write when they make up tool
+// results, instead of calling the tools: the markup of chat templates, mods'
+// own tool call output, and the labels of ReAct-style prompts.
+var fakeToolResults = regexp.MustCompile(
+	`(?im)` +
+		`</?(tool_result|tool_response|tool_output|function_results?|function_output)>` +
+		`|\[/?TOOL_RESULTS\]|<\|(tool_call|tool_result|python_tag)\|>` +
+		`|"tool_call_id"\s*:` +
+		`|^\s*>?\s*Ran tool:` +
+		`|^\s*(tool|function) (result|output|response)s?\s*:` +
+		`|^\s*observation\s*:`,
+)
+
+// looksLikeFakeToolResults returns whether the given answer has content that
+// looks like the results of a tool call.
+func looksLikeFakeToolResults(s string) bool {
+	return fakeToolResults.MatchString(s)
+}
+
+// rejectFakeToolResults asks the model again, with --strict-tools, if tools
+// were available but its answer has made up tool results instead of calls.
+//
+// The rejected answer is removed from the output, and kept in the
+// conversation, so the model knows what it did wrong.
+func (m *Mods) rejectFakeToolResults() (tea.Cmd, bool) {
+	if !m.Config.StrictTools || m.toolsChecked {
+		return nil, false
+	}
+	if n := len(m.messages); m.toolsEnabled && n > 0 {
+		last := m.messages[n-1]
+		if last.Role == proto.RoleAssistant && len(last.ToolCalls) == 0 && looksLikeFakeToolResults(last.Content) {
+			if m.strictRetries < m.Config.StrictToolsRetries {
+				m.strictRetries++
+				slog.Warn("the answer has made up tool results, asking again", "attempt", m.strictRetries)
+				m.resetTurnOutput()
+				m.history = m.messages
+				m.state = requestState
+				return m.startCompletionCmd(strictToolsPrompt), true
+			}
+			slog.Warn("the answer has made up tool results, giving up", "attempts", m.strictRetries)
+		}
+	}
+
+	m.toolsChecked = true
+	if m.toolsEnabled && (!isOutputTTY() || m.Config.Raw) && !m.shouldPostProcess() {
+		// print what was held back while it wasn't checked, i.e. the answer
+		// of this turn.
+		start := min(m.turnStart, len(m.Output))
+		output := m.Output[start:]
+		m.Output = m.Output[:start]
+		m.trim = edgeTrimmer{}
+		m.appendToOutput(output)
+	}
+	return nil, false
+}
+
+// holdOutput returns whether the raw output should not be printed yet, as it
+// might still change.
+func (m *Mods) holdOutput() bool {
+	return m.shouldPostProcess() || (m.Config.StrictTools && m.toolsEnabled && !m.toolsChecked)
+}
+
+// resetTurnOutput removes the output of the current completion.
+func (m *Mods) resetTurnOutput() {
+	output := m.Output[:min(m.turnStart, len(m.Output))]
+	m.Output = ""
+	m.glamOutput = ""
+	m.glamHeight = 0
+	m.glamViewport.SetContent("")
+	m.trim = edgeTrimmer{}
+	m.lineBuf = lineBuffer{}
+	if output != "" {
+		m.appendToOutput(output)
+	}
+	m.startTurnOutput()
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/charmbracelet/mods/internal/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLooksLikeFakeToolResults(t *testing.T) {
+	for input, expect := range map[string]bool{
+		"The weather in Lisbon is sunny.":                             false,
+		"You can use the `ls` tool to list files.":                    false,
+		"The function returns the result: 42.":                        false,
+		"<tool_result>\n{\"temp\": 21}\n</tool_result>\nIt's sunny.":  true,
+		"<function_results>21C</function_results>":                    true,
+		"[TOOL_RESULTS] {\"content\": 21} [/TOOL_RESULTS]":            true,
+		`{"role": "tool", "tool_call_id": "call_1", "content": "21"}`: true,
+		"> Ran tool: `get_weather`\n\nIt's 21C.":                      true,
+		"Tool result: 21C\n\nIt's warm.":                              true,
+		"Thought: I should check.\nObservation: it's 21C":             true,
+	} {
+		t.Run(input, func(t *testing.T) {
+			require.Equal(t, expect, looksLikeFakeToolResults(input))
+		})
+	}
+}
+
+func TestRejectFakeToolResults(t *testing.T) {
+	fake := "Tool result: 21C\n\nIt's warm."
+	newMods := func(answer string, strict, tools bool) *Mods {
+		m := &Mods{
+			Config: &Config{
+				StrictTools:        strict,
+				StrictToolsRetries: 1,
+			},
+			contentMutex: &sync.Mutex{},
+			toolsEnabled: tools,
+			messages: []proto.Message{
+				{Role: proto.RoleUser, Content: "weather in lisbon?"},
+				{Role: proto.RoleAssistant, Content: answer},
+			},
+		}
+		// as in Update, the prompt is echoed before the answer is checked.
+		m.toolsChecked = true
+		m.appendToOutput("weather in lisbon?\n\n")
+		m.turnStart = len(m.Output)
+		m.toolsChecked = false
+		m.appendToOutput(answer)
+		return m
+	}
+	printed := func(m *Mods) string {
+		return strings.Join(m.content, "") + m.lineBuf.flush()
+	}
+
+	t.Run("asks again", func(t *testing.T) {
+		m := newMods(fake, true, true)
+		require.Equal(t, []string{"weather in lisbon?\n\n"}, m.content, "the answer should be held until checked")
+
+		cmd, ok := m.rejectFakeToolResults()
+		require.True(t, ok)
+		require.NotNil(t, cmd)
+		require.Equal(t, 1, m.strictRetries)
+		require.Equal(t, "weather in lisbon?\n\n", m.Output)
+		require.Len(t, m.history, 2)
+
+		// the second answer is made up too, but there are no retries left.
+		m.messages = append(m.history, proto.Message{Role: proto.RoleAssistant, Content: fake})
+		m.appendToOutput(fake)
+		_, ok = m.rejectFakeToolResults()
+		require.False(t, ok)
+		require.True(t, m.toolsChecked)
+		require.Equal(t, "weather in lisbon?\n\n"+fake, printed(m))
+	})
+
+	t.Run("real answer", func(t *testing.T) {
+		m := newMods("It's sunny.", true, true)
+		_, ok := m.rejectFakeToolResults()
+		require.False(t, ok)
+		require.True(t, m.toolsChecked)
+		require.Equal(t, "weather in lisbon?\n\nIt's sunny.", printed(m))
+	})
+
+	t.Run("answer with tool calls", func(t *testing.T) {
+		m := newMods(fake, true, true)
+		m.messages[1].ToolCalls = []proto.ToolCall{{ID: "call_1"}}
+		_, ok := m.rejectFakeToolResults()
+		require.False(t, ok)
+	})
+
+	t.Run("no tools", func(t *testing.T) {
+		m := newMods(fake, true, false)
+		require.NotEmpty(t, m.content, "output should not be held")
+		_, ok := m.rejectFakeToolResults()
+		require.False(t, ok)
+		require.Equal(t, "weather in lisbon?\n\n"+fake, printed(m), "output should be printed once")
+	})
+
+	t.Run("next turn is checked again", func(t *testing.T) {
+		m := newMods("It's sunny.", true, true)
+		_, ok := m.rejectFakeToolResults()
+		require.False(t, ok)
+		m.strictRetries = 1
+		m.Config.Quiet = true
+		_, _ = m.Update(completionInput{"and tomorrow?"})
+		require.False(t, m.toolsChecked)
+		require.Zero(t, m.strictRetries)
+	})
+
+	t.Run("not strict", func(t *testing.T) {
+		m := newMods(fake, false, true)
+		_, ok := m.rejectFakeToolResults()
+		require.False(t, ok)
+		require.Equal(t, "weather in lisbon?\n\n"+fake, printed(m))
+	})
+}
+
+func TestStrictToolsPromptEcho(t *testing.T) {
+	for name, answer := range map[string]string{
+		"real answer":         "It's sunny.",
+		"made up, no retries": "Tool result: 21C\n\nIt's warm.",
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := &Mods{
+				Config: &Config{
+					Quiet:             true,
+					Prefix:            "weather in lisbon?",
+					IncludePromptArgs: true,
+					StrictTools:       true,
+					MCPServers:        map[string]MCPServerConfig{"weather": {Command: "weather-mcp"}},
+				},
+				contentMutex: &sync.Mutex{},
+			}
+			_, _ = m.Update(completionInput{"weather in lisbon?"})
+			require.True(t, m.toolsEnabled)
+			require.Equal(t, "weather in lisbon?\n\n", strings.Join(m.content, ""), "the prompt should not be held")
+
+			m.messages = []proto.Message{
+				{Role: proto.RoleUser, Content: "weather in lisbon?"},
+				{Role: proto.RoleAssistant, Content: answer},
+			}
+			m.appendToOutput(answer)
+			_, _ = m.Update(completionOutput{})
+			require.Equal(t, doneState, m.state)
+			require.Equal(t, "weather in lisbon?\n\n"+answer, strings.Join(m.content, "")+m.lineBuf.flush())
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// defaultStripTokens are the end tokens of common chat templates, which
+// local models sometimes leak into their output.
+var defaultStripTokens = []string{
+	"<|im_end|>",
+	"<|eot_id|>",
+	"<|end_of_text|>",
+	"<|endoftext|>",
+	"<|end|>",
+	"<end_of_turn>",
+	"</s>",
+}
+
+// localAPIs are the APIs that use [defaultStripTokens] unless the model sets
+// its own strip-tokens.
+var localAPIs = []string{"ollama", "localai"}
+
+// stripTokens returns the tokens to remove from the output of the given
+// model.
+func stripTokens(mod Model) []string {
+	if mod.StripTokens == nil && slices.Contains(localAPIs, mod.API) {
+		return defaultStripTokens
+	}
+	return mod.StripTokens
+}
+
+// tokenStripper removes tokens from streamed content.
+//
+// As a token may be split across chunks, the end of a chunk that could be the
+// start of a token is held until the next one.
+type tokenStripper struct {
+	tokens  []string
+	pending string
+}
+
+func newTokenStripper(tokens []string) *tokenStripper {
+	tokens = slices.DeleteFunc(slices.Clone(tokens), func(s string) bool { return s == "" })
+	if len(tokens) == 0 {
+		return nil
+	}
+	return &tokenStripper{tokens: tokens}
+}
+
+// write returns the given content without the tokens, minus what's held.
+func (t *tokenStripper) write(s string) string {
+	if t == nil {
+		return s
+	}
+	s = t.strip(t.pending + s)
+	var hold int
+	for _, token := range t.tokens {
+		for n := min(len(token)-1, len(s)); n > hold; n-- {
+			if strings.HasSuffix(s, token[:n]) {
+				hold = n
+				break
+			}
+		}
+	}
+	t.pending = s[len(s)-hold:]
+	return s[:len(s)-hold]
+}
+
+// flush returns whatever is held, as the content ended without completing a
+// token.
+func (t *tokenStripper) flush() string {
+	if t == nil {
+		return ""
+	}
+	s := t.pending
+	t.pending = ""
+	return s
+}
+
+// strip removes all the tokens from the given content.
+func (t *tokenStripper) strip(s string) string {
+	if t == nil {
+		return s
+	}
+	for _, token := range t.tokens {
+		s = strings.ReplaceAll(s, token, "")
+	}
+	return s
+}
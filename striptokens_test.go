@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenStripper(t *testing.T) {
+	tokens := []string{"<|im_end|>", "</s>"}
+	for name, tc := range map[string]struct {
+		chunks   []string
+		expected string
+	}{
+		"no tokens": {
+			chunks:   []string{"hello", " world"},
+			expected: "hello world",
+		},
+		"whole token": {
+			chunks:   []string{"hello", " world<|im_end|>"},
+			expected: "hello world",
+		},
+		"token split across chunks": {
+			chunks:   []string{"hello<|im", "_end|> world"},
+			expected: "hello world",
+		},
+		"token split across many chunks": {
+			chunks:   []string{"hello", "<", "|", "im_e", "nd|", ">", "</", "s>"},
+			expected: "hello",
+		},
+		"looks like a token but is not": {
+			chunks:   []string{"a <", "b> c </", "div>"},
+			expected: "a <b> c </div>",
+		},
+		"incomplete token at the end": {
+			chunks:   []string{"1 <", "|im"},
+			expected: "1 <|im",
+		},
+		"many tokens": {
+			chunks:   []string{"a</s>b<|im_end|>", "c</s", ">"},
+			expected: "abc",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			strip := newTokenStripper(tokens)
+			var sb strings.Builder
+			for _, chunk := range tc.chunks {
+				sb.WriteString(strip.write(chunk))
+			}
+			sb.WriteString(strip.flush())
+			require.Equal(t, tc.expected, sb.String())
+		})
+	}
+
+	t.Run("no tokens to strip", func(t *testing.T) {
+		strip := newTokenStripper([]string{""})
+		require.Nil(t, strip)
+		require.Equal(t, "a</s>", strip.write("a</s>"))
+		require.Empty(t, strip.flush())
+	})
+}
+
+func TestStripTokens(t *testing.T) {
+	require.Equal(t, defaultStripTokens, stripTokens(Model{API: "ollama"}))
+	require.Empty(t, stripTokens(Model{API: "ollama", StripTokens: []string{}}))
+	require.Equal(t, []string{"<eos>"}, stripTokens(Model{API: "ollama", StripTokens: []string{"<eos>"}}))
+	require.Empty(t, stripTokens(Model{API: "openai"}))
+	require.Equal(t, []string{"<eos>"}, stripTokens(Model{API: "openai", StripTokens: []string{"<eos>"}}))
+}
@@ -24,6 +24,7 @@ type styles struct {
 	Quote,
 	ConversationList,
 	SHA1,
+	Favorite,
 	Timeago lipgloss.Style
 }
 
@@ -45,10 +46,13 @@ func makeStyles(r *lipgloss.Renderer) (s styles) {
 	s.Pipe = r.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#8470FF", Dark: "#745CFF"})
 	s.ConversationList = r.NewStyle().Padding(0, 1)
 	s.SHA1 = s.Flag
+	s.Favorite = r.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#F5A900", Dark: "#FFD75F"})
 	s.Timeago = r.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#999", Dark: "#555"})
 	return s
 }
 
+const favoriteStar = "★"
+
 // action messages
 
 const defaultAction = "WROTE"
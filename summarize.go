@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// The lengths of the summaries of --summarize.
+const (
+	summaryShort  = "short"
+	summaryMedium = "medium"
+	summaryLong   = "long"
+)
+
+var summaryLengths = []string{summaryShort, summaryMedium, summaryLong}
+
+// summarizeRole is the name of the role used by --summarize for the given
+// length.
+func summarizeRole(length string) string {
+	return "summarize-" + length
+}
+
+// defaultSummarizeRoles are the roles used by --summarize, by name. They are
+// also in the settings template, so they can be tuned, but are used as they
+// are here if they are not in the settings.
+func defaultSummarizeRoles() map[string][]string {
+	const clean = "reply with the summary only, as plain text, without a title, an introduction, or markdown"
+	return map[string][]string{
+		summarizeRole(summaryShort): {
+			"you summarize the text you are given",
+			"the summary is one to three sentences with its main point",
+			clean,
+		},
+		summarizeRole(summaryMedium): {
+			"you summarize the text you are given",
+			"the summary is a paragraph or two with its main points, in the order they appear",
+			clean,
+		},
+		summarizeRole(summaryLong): {
+			"you summarize the text you are given",
+			"the summary covers all of its points, with the important details, a paragraph for each topic",
+			clean,
+		},
+	}
+}
+
+// applySummarize sets up --summarize: it picks the summarization role for
+// --length, and raw output, unless a format was asked for with --format or
+// --format-as.
+func applySummarize(cfg *Config, flagSet func(name string) bool) error {
+	if !cfg.Summarize {
+		if flagSet("length") {
+			return modsError{
+				err: newUserErrorf(
+					"Try %s.",
+					stderrStyles().InlineCode.Render("mods --summarize --length "+cfg.SummaryLength),
+				),
+				reason: fmt.Sprintf(
+					"%s only works with %s.",
+					stderrStyles().InlineCode.Render("--length"),
+					stderrStyles().InlineCode.Render("--summarize"),
+				),
+			}
+		}
+		return nil
+	}
+	if !slices.Contains(summaryLengths, cfg.SummaryLength) {
+		return modsError{
+			err: newUserErrorf(
+				"Valid lengths are: %s",
+				strings.Join(summaryLengths, ", "),
+			),
+			reason: fmt.Sprintf("Invalid summary length %q.", cfg.SummaryLength),
+		}
+	}
+
+	role := summarizeRole(cfg.SummaryLength)
+	if _, ok := cfg.Roles[role]; !ok {
+		if cfg.Roles == nil {
+			cfg.Roles = map[string][]string{}
+		}
+		cfg.Roles[role] = defaultSummarizeRoles()[role]
+	}
+	cfg.Role = role
+	cfg.RoleURL = ""
+
+	if !flagSet("format") && !flagSet("format-as") {
+		cfg.Format = false
+		cfg.Raw = true
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestApplySummarize(t *testing.T) {
+	flags := func(names ...string) func(string) bool {
+		return func(name string) bool { return slices.Contains(names, name) }
+	}
+
+	t.Run("off", func(t *testing.T) {
+		cfg := Config{Role: "default", SummaryLength: summaryMedium}
+		require.NoError(t, applySummarize(&cfg, flags()))
+		require.Equal(t, "default", cfg.Role)
+		require.False(t, cfg.Raw)
+	})
+
+	t.Run("length without summarize", func(t *testing.T) {
+		cfg := Config{SummaryLength: summaryShort}
+		err := applySummarize(&cfg, flags("length"))
+		require.Error(t, err)
+		require.Contains(t, err.(modsError).reason, "only works with")
+	})
+
+	t.Run("invalid length", func(t *testing.T) {
+		cfg := Config{Summarize: true, SummaryLength: "tiny"}
+		err := applySummarize(&cfg, flags("length"))
+		require.Error(t, err)
+		require.Equal(t, `Invalid summary length "tiny".`, err.(modsError).reason)
+	})
+
+	t.Run("built in role", func(t *testing.T) {
+		cfg := Config{Summarize: true, SummaryLength: summaryShort, Role: "default", Format: true}
+		require.NoError(t, applySummarize(&cfg, flags()))
+		require.Equal(t, "summarize-short", cfg.Role)
+		require.Equal(t, defaultSummarizeRoles()["summarize-short"], cfg.Roles["summarize-short"])
+		require.True(t, cfg.Raw)
+		require.False(t, cfg.Format)
+	})
+
+	t.Run("role from the settings", func(t *testing.T) {
+		cfg := Config{
+			Summarize:     true,
+			SummaryLength: summaryLong,
+			Roles:         map[string][]string{"summarize-long": {"summarize it all"}},
+		}
+		require.NoError(t, applySummarize(&cfg, flags()))
+		require.Equal(t, []string{"summarize it all"}, cfg.Roles["summarize-long"])
+	})
+
+	t.Run("format", func(t *testing.T) {
+		cfg := Config{Summarize: true, SummaryLength: summaryMedium, Format: true, FormatAs: "json"}
+		require.NoError(t, applySummarize(&cfg, flags("format-as")))
+		require.True(t, cfg.Format)
+		require.False(t, cfg.Raw)
+	})
+}
+
+func TestSummarizeRolesInTemplate(t *testing.T) {
+	var out bytes.Buffer
+	tmpl := template.Must(template.New("config").Parse(configTemplate))
+	require.NoError(t, tmpl.Execute(&out, struct {
+		Config Config
+		Help   map[string]string
+	}{defaultConfig(), help}))
+
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal(out.Bytes(), &cfg))
+	for name, role := range defaultSummarizeRoles() {
+		require.Equal(t, role, cfg.Roles[name], name)
+	}
+}
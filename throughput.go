@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// charsPerToken is a rough estimate of how many characters make a token.
+const charsPerToken = 4
+
+// throughput measures how fast a completion is being generated.
+type throughput struct {
+	start time.Time
+	first time.Duration
+	total time.Duration
+	chars int
+}
+
+// observe records a chunk of the completion.
+func (t *throughput) observe(content string) {
+	if t.start.IsZero() || content == "" {
+		return
+	}
+	elapsed := time.Since(t.start)
+	if t.chars == 0 {
+		t.first = elapsed
+	}
+	t.total = elapsed
+	t.chars += len(content)
+}
+
+// tokens returns the estimated number of tokens received.
+func (t throughput) tokens() int {
+	return (t.chars + charsPerToken - 1) / charsPerToken
+}
+
+// perToken returns the average time to generate each token, excluding the
+// time to the first one.
+func (t throughput) perToken() time.Duration {
+	tokens := t.tokens()
+	if tokens <= 1 {
+		return 0
+	}
+	return (t.total - t.first) / time.Duration(tokens-1)
+}
+
+// slow returns whether generating each token took longer than max.
+func (t throughput) slow(maxPerToken time.Duration) bool {
+	return maxPerToken > 0 && t.perToken() > maxPerToken
+}
+
+// throughputReport returns the stats of the last completion, and a warning if
+// it was slower than max-time-per-token.
+// It is only shown with --verbose.
+func (m *Mods) throughputReport() string {
+	t := m.throughput
+	if !m.Config.Verbose || t.chars == 0 {
+		return ""
+	}
+	report := stderrStyles().Comment.Render(fmt.Sprintf(
+		"Received ~%d tokens in %s (first token after %s, ~%s per token).",
+		t.tokens(),
+		t.total.Round(time.Millisecond),
+		t.first.Round(time.Millisecond),
+		t.perToken().Round(time.Microsecond),
+	))
+	if !t.slow(m.Config.MaxTimePerToken) {
+		return report
+	}
+
+	report += "\n" + stderrStyles().Comment.Render(fmt.Sprintf(
+		"Generation was slower than %s per token, %s on %s may be degraded.",
+		m.Config.MaxTimePerToken,
+		m.model.Name,
+		m.model.API,
+	))
+	if m.model.Fallback != "" {
+		report += " " + stderrStyles().Comment.Render("Consider using") + " " +
+			stderrStyles().InlineCode.Render("--model "+m.model.Fallback) + "."
+	}
+	return report
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThroughput(t *testing.T) {
+	t.Run("not started", func(t *testing.T) {
+		var tp throughput
+		tp.observe("hello")
+		require.Zero(t, tp.tokens())
+	})
+
+	t.Run("per token", func(t *testing.T) {
+		tp := throughput{
+			first: time.Second,
+			total: 3 * time.Second,
+			chars: 12,
+		}
+		require.Equal(t, 3, tp.tokens())
+		require.Equal(t, time.Second, tp.perToken())
+		require.True(t, tp.slow(500*time.Millisecond))
+		require.False(t, tp.slow(time.Second))
+		require.False(t, tp.slow(0))
+	})
+
+	t.Run("observe", func(t *testing.T) {
+		tp := throughput{start: time.Now().Add(-time.Second)}
+		tp.observe("abcd")
+		tp.observe("")
+		tp.observe("efgh")
+		require.Equal(t, 2, tp.tokens())
+		require.GreaterOrEqual(t, tp.first, time.Second)
+		require.GreaterOrEqual(t, tp.total, tp.first)
+	})
+}
+
+func TestThroughputReport(t *testing.T) {
+	m := &Mods{
+		Config: &Config{Verbose: true, MaxTimePerToken: 500 * time.Millisecond, Model: "fast"},
+		model:  Model{Name: "llama3", API: "gpu-2", Fallback: "gpt-4o-mini"},
+		throughput: throughput{
+			first: time.Second,
+			total: 3 * time.Second,
+			chars: 12,
+		},
+	}
+	report := m.throughputReport()
+	require.Contains(t, report, "Received ~3 tokens in 3s")
+	require.Contains(t, report, "llama3 on gpu-2 may be degraded")
+	require.Contains(t, report, "--model gpt-4o-mini")
+	require.Equal(t, "fast", m.Config.Model, "the settings should not change")
+
+	m.Config.Verbose = false
+	require.Empty(t, m.throughputReport())
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// timestamper prefixes each line of the raw output with the time it was
+// printed, with --timestamp.
+type timestamper struct {
+	format  string
+	midLine bool
+	now     func() time.Time
+}
+
+func newTimestamper(cfg *Config) *timestamper {
+	if !cfg.Timestamp {
+		return nil
+	}
+	format := cfg.TimestampFormat
+	if format == "" {
+		format = time.RFC3339
+	}
+	return &timestamper{format: format, now: time.Now}
+}
+
+// write returns the given content, with the timestamp at the start of each
+// line in it, including the ones that start in later writes.
+func (t *timestamper) write(s string) string {
+	if t == nil || s == "" {
+		return s
+	}
+	prefix := t.now().Format(t.format) + " "
+	var sb strings.Builder
+	for line := range strings.SplitAfterSeq(s, "\n") {
+		if line == "" {
+			continue
+		}
+		if !t.midLine {
+			sb.WriteString(prefix)
+		}
+		sb.WriteString(line)
+		t.midLine = !strings.HasSuffix(line, "\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestamper(t *testing.T) {
+	now := time.Date(2025, 5, 4, 13, 30, 0, 0, time.UTC)
+	newStamper := func(format string) *timestamper {
+		s := newTimestamper(&Config{Timestamp: true, TimestampFormat: format})
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		s := newTimestamper(&Config{TimestampFormat: time.RFC3339})
+		require.Nil(t, s)
+		require.Equal(t, "hello\n", s.write("hello\n"))
+	})
+
+	t.Run("chunks", func(t *testing.T) {
+		s := newStamper("")
+		var out strings.Builder
+		for _, chunk := range []string{"hel", "lo\nwor", "ld\n", "\n", "foo"} {
+			out.WriteString(s.write(chunk))
+		}
+		require.Equal(t, strings.Join([]string{
+			"2025-05-04T13:30:00Z hello",
+			"2025-05-04T13:30:00Z world",
+			"2025-05-04T13:30:00Z ",
+			"2025-05-04T13:30:00Z foo",
+		}, "\n"), out.String())
+	})
+
+	t.Run("line buffered", func(t *testing.T) {
+		s := newStamper("")
+		var b lineBuffer
+		var out strings.Builder
+		for _, chunk := range []string{"hel", "lo\nwor", "ld", "\n", "foo\nbar\nba", "z"} {
+			out.WriteString(s.write(b.write(chunk)))
+		}
+		out.WriteString(s.write(b.flush()))
+		require.Equal(t, strings.Join([]string{
+			"2025-05-04T13:30:00Z hello",
+			"2025-05-04T13:30:00Z world",
+			"2025-05-04T13:30:00Z foo",
+			"2025-05-04T13:30:00Z bar",
+			"2025-05-04T13:30:00Z baz",
+		}, "\n"), out.String())
+	})
+
+	t.Run("custom format", func(t *testing.T) {
+		s := newStamper("15:04:05.000")
+		require.Equal(t, "13:30:00.000 hi\n", s.write("hi\n"))
+	})
+}
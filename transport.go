@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+const (
+	ipVersionAuto = "auto"
+	ipVersion4    = "4"
+	ipVersion6    = "6"
+)
+
+var ipVersions = []string{ipVersionAuto, ipVersion4, ipVersion6}
+
+// ipNetwork returns the network to dial for the given IP version.
+func ipNetwork(version string) string {
+	switch version {
+	case ipVersion4:
+		return "tcp4"
+	case ipVersion6:
+		return "tcp6"
+	default:
+		return "tcp"
+	}
+}
+
+// apiHTTPClient returns the HTTP client to connect to the API endpoints with,
+// running the interceptors of the plugins, or nil if the default one can be
+// used.
+func apiHTTPClient(cfg *Config) (*http.Client, error) {
+	network := ipNetwork(cfg.IPVersion)
+	interceptors := loadPlugins(cfg.Plugins)
+	if cfg.HTTPProxy == "" && network == "tcp" && len(interceptors) == 0 {
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.HTTPProxy != "" {
+		proxyURL, err := url.Parse(cfg.HTTPProxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if network != "tcp" {
+		// dial only the chosen network, instead of trying both.
+		var dialer net.Dialer
+		transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+	if len(interceptors) > 0 {
+		return &http.Client{Transport: interceptTransport{transport, interceptors}}, nil
+	}
+	return &http.Client{Transport: transport}, nil
+}
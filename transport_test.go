@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIHTTPClient(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		client, err := apiHTTPClient(&Config{IPVersion: ipVersionAuto})
+		require.NoError(t, err)
+		require.Nil(t, client)
+	})
+
+	t.Run("invalid proxy", func(t *testing.T) {
+		_, err := apiHTTPClient(&Config{HTTPProxy: "://nope"})
+		require.Error(t, err)
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		client, err := apiHTTPClient(&Config{HTTPProxy: "http://localhost:8080"})
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodGet, "https://api.openai.com", nil)
+		require.NoError(t, err)
+		proxy, err := client.Transport.(*http.Transport).Proxy(req)
+		require.NoError(t, err)
+		require.Equal(t, "http://localhost:8080", proxy.String())
+	})
+
+	t.Run("ip version", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+		t.Cleanup(srv.Close)
+
+		client, err := apiHTTPClient(&Config{IPVersion: ipVersion4})
+		require.NoError(t, err)
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		client, err = apiHTTPClient(&Config{IPVersion: ipVersion6})
+		require.NoError(t, err)
+		_, err = client.Get(srv.URL) //nolint:bodyclose
+		require.Error(t, err, "should not dial the IPv4 address")
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// trimEdges removes the blank lines at the start of the given content, and
+// all whitespace at its end.
+//
+// The indentation of the first line is kept, as it may be significant, e.g.
+// in code.
+func trimEdges(s string) string {
+	var t edgeTrimmer
+	return t.write(s)
+}
+
+// edgeTrimmer does the same as [trimEdges] on streamed content: leading
+// blank lines are dropped, and trailing whitespace is held until more
+// content arrives, so it's never written if it's at the very end.
+type edgeTrimmer struct {
+	started  bool
+	leading  string
+	trailing string
+}
+
+// write returns the part of the given content that can be written.
+func (t *edgeTrimmer) write(s string) string {
+	if !t.started {
+		s = t.leading + s
+		idx := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsSpace(r) })
+		if idx < 0 {
+			t.leading = s
+			return ""
+		}
+		t.started, t.leading = true, ""
+		s = s[strings.LastIndexByte(s[:idx], '\n')+1:]
+	}
+
+	s = t.trailing + s
+	trimmed := strings.TrimRightFunc(s, unicode.IsSpace)
+	t.trailing = s[len(trimmed):]
+	return trimmed
+}
+
+// restart makes the trimmer start over, e.g. for the answer written after
+// the echoed prompt. It returns the whitespace held at the end of what was
+// written before, as it's no longer at the very end.
+func (t *edgeTrimmer) restart() string {
+	held := t.trailing
+	*t = edgeTrimmer{}
+	return held
+}
+
+// trimOutput returns whether the edges of the response should be trimmed.
+func (m *Mods) trimOutput() bool {
+	return m.Config.TrimOutput && !m.Config.NoTrim
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrimEdges(t *testing.T) {
+	for name, tc := range map[string]struct {
+		chunks   []string
+		expected string
+	}{
+		"nothing to trim": {
+			chunks:   []string{"hello", " world"},
+			expected: "hello world",
+		},
+		"blank lines around": {
+			chunks:   []string{"\n", "\n  \n", "hello\n", "\n\n"},
+			expected: "hello",
+		},
+		"keeps indentation of the first line": {
+			chunks:   []string{"\n\n    ", "func main() {}\n", "\n"},
+			expected: "    func main() {}",
+		},
+		"keeps whitespace inside": {
+			chunks:   []string{"```go\n", "func main() {\n", "\n\n", "\tprintln()\n}\n```", "\n  "},
+			expected: "```go\nfunc main() {\n\n\n\tprintln()\n}\n```",
+		},
+		"keeps trailing whitespace followed by content": {
+			chunks:   []string{"a ", " ", "\n", "b"},
+			expected: "a  \nb",
+		},
+		"only whitespace": {
+			chunks:   []string{"\n", " \t"},
+			expected: "",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var trim edgeTrimmer
+			var sb strings.Builder
+			for _, chunk := range tc.chunks {
+				sb.WriteString(trim.write(chunk))
+			}
+			require.Equal(t, tc.expected, sb.String())
+			require.Equal(t, tc.expected, trimEdges(strings.Join(tc.chunks, "")))
+		})
+	}
+}
+
+func TestTrimOutputPromptEcho(t *testing.T) {
+	newMods := func(cfg Config) *Mods {
+		cfg.Quiet = true
+		cfg.TrimOutput = true
+		cfg.IncludePromptArgs = true
+		cfg.Prefix = "first 2 primes"
+		return &Mods{Config: &cfg, contentMutex: &sync.Mutex{}}
+	}
+	answer := []string{"\n", "\n  \n", "2 and 3", "\n\n"}
+
+	t.Run("trims the answer", func(t *testing.T) {
+		m := newMods(Config{})
+		_, _ = m.Update(completionInput{"first 2 primes"})
+		for _, chunk := range answer {
+			m.appendToOutput(chunk)
+		}
+		require.Equal(t, "first 2 primes\n\n2 and 3", m.Output)
+		require.Equal(t, "2 and 3", m.Output[m.turnStart:])
+	})
+
+	t.Run("trims the answer when asking again", func(t *testing.T) {
+		m := newMods(Config{StrictTools: true, StrictToolsRetries: 1})
+		_, _ = m.Update(completionInput{"first 2 primes"})
+		m.appendToOutput("Tool result: 2, 3")
+		m.resetTurnOutput()
+		require.Equal(t, "first 2 primes\n\n", m.Output)
+		for _, chunk := range answer {
+			m.appendToOutput(chunk)
+		}
+		require.Equal(t, "first 2 primes\n\n2 and 3", m.Output)
+	})
+}